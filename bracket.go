@@ -0,0 +1,512 @@
+package rvglutils
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type BracketFormat string
+
+const (
+	SingleElimination BracketFormat = "single"
+	DoubleElimination BracketFormat = "double"
+	RoundRobin        BracketFormat = "round-robin"
+)
+
+type BracketStage string
+
+const (
+	StageWinners    BracketStage = "winners"
+	StageLosers     BracketStage = "losers"
+	StageFinal      BracketStage = "final"
+	StageReset      BracketStage = "reset"
+	StageRoundRobin BracketStage = "round-robin"
+)
+
+type NewBracketOpts struct {
+	HeatSize int
+	Advance  int
+}
+
+func (o *NewBracketOpts) Apply(opts *NewBracketOpts) {
+	if o != nil {
+		if opts != nil {
+			if o.HeatSize > 0 {
+				opts.HeatSize = o.HeatSize
+			}
+			if o.Advance > 0 {
+				opts.Advance = o.Advance
+			}
+		}
+	}
+}
+
+type NewBracketOpt interface {
+	Apply(*NewBracketOpts)
+}
+
+// Bracket is a tournament spanning sessions. Each Heat is raced as a session
+// or a subset of a session's races, after which the top Advance players of
+// the heat move on.
+type Bracket struct {
+	Name     string        `json:"name"`
+	Format   BracketFormat `json:"format"`
+	HeatSize int           `json:"heatSize"`
+	Advance  int           `json:"advance"`
+	// Players are ordered by seed.
+	Players []string `json:"players"`
+	Rounds  []Round  `json:"rounds"`
+	// Losers are players in the losers bracket waiting for a heat.
+	Losers []string `json:"losers,omitempty"`
+	// Finalists are the players that made it out of the winners bracket.
+	Finalists []string `json:"finalists,omitempty"`
+	Champion  string   `json:"champion,omitempty"`
+}
+
+type Round struct {
+	Number int          `json:"number"`
+	Stage  BracketStage `json:"stage"`
+	Heats  []Heat       `json:"heats"`
+}
+
+type Heat struct {
+	ID        string   `json:"id"`
+	Players   []string `json:"players"`
+	Session   string   `json:"session,omitempty"`
+	Races     string   `json:"races,omitempty"`
+	Standings []Score  `json:"standings,omitempty"`
+	Done      bool     `json:"done"`
+	// Bye is set on heats with too few players to eliminate anyone,
+	// whose players advance without racing.
+	Bye bool `json:"bye,omitempty"`
+}
+
+func newNewBracketOpts(opts ...NewBracketOpt) *NewBracketOpts {
+	o := &NewBracketOpts{
+		HeatSize: 4,
+	}
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	if o.Advance <= 0 {
+		o.Advance = o.HeatSize / 2
+	}
+
+	return o
+}
+
+// NewBracket creates a bracket of the given format for players, who are
+// expected to be ordered by seed.
+func NewBracket(name string, format BracketFormat, players []string, opts ...NewBracketOpt) (*Bracket, error) {
+	o := newNewBracketOpts(opts...)
+
+	if len(players) < 2 {
+		return nil, fmt.Errorf("bracket needs at least 2 players")
+	}
+
+	b := &Bracket{
+		Name:     name,
+		Format:   format,
+		HeatSize: o.HeatSize,
+		Advance:  o.Advance,
+		Players:  players,
+	}
+
+	switch format {
+	case SingleElimination, DoubleElimination:
+		if o.HeatSize < 2 {
+			return nil, fmt.Errorf("heat size must be at least 2")
+		}
+
+		// Advancing at most half of each heat guarantees that every round shrinks.
+		if o.Advance < 1 || o.Advance > o.HeatSize/2 {
+			return nil, fmt.Errorf("number of players advancing from each heat must be between 1 and %d", o.HeatSize/2)
+		}
+
+		b.addRound(1, StageWinners, players)
+	case RoundRobin:
+		// Each player races each other player head-to-head once,
+		// paired up using the circle method.
+		b.HeatSize = 2
+		b.Advance = 1
+
+		circle := slices.Clone(players)
+		if len(circle)%2 == 1 {
+			circle = append(circle, "")
+		}
+
+		n := len(circle)
+		for r := 0; r < n-1; r++ {
+			round := Round{Number: r + 1, Stage: StageRoundRobin}
+
+			for i := 0; i < n/2; i++ {
+				a, c := circle[i], circle[n-1-i]
+				if a == "" || c == "" {
+					continue
+				}
+
+				round.Heats = append(round.Heats, Heat{
+					ID:      fmt.Sprintf("R%d-%d", r+1, len(round.Heats)+1),
+					Players: []string{a, c},
+				})
+			}
+
+			b.Rounds = append(b.Rounds, round)
+
+			// Keep the first player fixed and rotate the rest.
+			circle = append([]string{circle[0], circle[n-1]}, circle[1:n-1]...)
+		}
+	default:
+		return nil, fmt.Errorf(`invalid bracket format %q, expected "single", "double" or "round-robin"`, format)
+	}
+
+	return b, nil
+}
+
+// addRound splits players into heats for a new round of the given stage,
+// distributing them by seed so that heats are as even as possible.
+func (b *Bracket) addRound(number int, stage BracketStage, players []string) {
+	var (
+		lenH  = (len(players) + b.HeatSize - 1) / b.HeatSize
+		heats = make([]Heat, lenH)
+	)
+
+	// Byes go to the first players, so hand them to the
+	// players who have had the fewest byes so far.
+	if lenH > 1 {
+		byes := map[string]int{}
+		for _, round := range b.Rounds {
+			for _, heat := range round.Heats {
+				if heat.Bye {
+					for _, player := range heat.Players {
+						byes[player]++
+					}
+				}
+			}
+		}
+
+		players = slices.Clone(players)
+		slices.SortStableFunc(players, func(a, c string) int {
+			return byes[a] - byes[c]
+		})
+	}
+
+	for i, player := range players {
+		var (
+			pass = i / lenH
+			j    = i % lenH
+		)
+		if pass%2 == 1 {
+			j = lenH - 1 - j
+		}
+
+		heats[j].Players = append(heats[j].Players, player)
+	}
+
+	for i := range heats {
+		heats[i].ID = fmt.Sprintf("%s%d-%d", strings.ToUpper(string(stage[:1])), number, i+1)
+
+		// A lone heat still decides the order of its players, but a heat
+		// that sits alongside others and eliminates nobody is a bye.
+		if lenH > 1 && len(heats[i].Players) <= b.Advance {
+			heats[i].Standings = make([]Score, len(heats[i].Players))
+			for j, player := range heats[i].Players {
+				heats[i].Standings[j] = Score{Player: player}
+			}
+
+			heats[i].Done = true
+			heats[i].Bye = true
+		}
+	}
+
+	b.Rounds = append(b.Rounds, Round{Number: number, Stage: stage, Heats: heats})
+}
+
+// Heat returns the heat with the given ID.
+func (b *Bracket) Heat(id string) *Heat {
+	for i := range b.Rounds {
+		for j := range b.Rounds[i].Heats {
+			if b.Rounds[i].Heats[j].ID == id {
+				return &b.Rounds[i].Heats[j]
+			}
+		}
+	}
+
+	return nil
+}
+
+// PendingHeats returns the heats that are ready to be raced.
+func (b *Bracket) PendingHeats() []*Heat {
+	heats := []*Heat{}
+
+	for i := range b.Rounds {
+		for j := range b.Rounds[i].Heats {
+			if !b.Rounds[i].Heats[j].Done {
+				heats = append(heats, &b.Rounds[i].Heats[j])
+			}
+		}
+	}
+
+	return heats
+}
+
+// MatchHeat returns the first pending heat whose players all raced in
+// the given session, or nil if there is none.
+func (b *Bracket) MatchHeat(session *Session) *Heat {
+	raced := map[string]bool{}
+	for _, race := range session.Races {
+		for _, result := range race.Results {
+			raced[result.Player] = true
+		}
+	}
+
+	for _, heat := range b.PendingHeats() {
+		if !slices.ContainsFunc(heat.Players, func(player string) bool {
+			return !raced[player]
+		}) {
+			return heat
+		}
+	}
+
+	return nil
+}
+
+// RecordHeat scores the heat with the given ID from the given session,
+// considering only the heat's players, and advances the bracket if that
+// completes the current round.
+func (b *Bracket) RecordHeat(id string, session *Session, opts ...ScoreSessionOpt) error {
+	heat := b.Heat(id)
+	if heat == nil {
+		return fmt.Errorf("no heat %q in bracket %q", id, b.Name)
+	} else if heat.Done {
+		return fmt.Errorf("heat %q in bracket %q is already done", id, b.Name)
+	}
+
+	points := map[string]float64{}
+	for _, score := range ScoreSession(session, append(opts, &ScoreSessionOpts{IncludeAI: true})...) {
+		points[score.Player] = score.Points
+	}
+
+	heat.Standings = make([]Score, len(heat.Players))
+	for i, player := range heat.Players {
+		heat.Standings[i] = Score{Player: player, Points: points[player]}
+	}
+
+	// Ties go to the better seed.
+	sort.SliceStable(heat.Standings, func(i, j int) bool {
+		return heat.Standings[i].Points > heat.Standings[j].Points
+	})
+
+	heat.Done = true
+	b.advance()
+
+	return nil
+}
+
+func (h *Heat) advancing(n int) ([]string, []string) {
+	var (
+		advancing  = []string{}
+		eliminated = []string{}
+	)
+	for i, score := range h.Standings {
+		if i < n {
+			advancing = append(advancing, score.Player)
+		} else {
+			eliminated = append(eliminated, score.Player)
+		}
+	}
+
+	return advancing, eliminated
+}
+
+func (b *Bracket) advance() {
+	if b.Champion != "" || len(b.PendingHeats()) > 0 || len(b.Rounds) == 0 {
+		return
+	}
+
+	if b.Format == RoundRobin {
+		standings := b.Standings()
+		if len(standings) > 0 {
+			b.Champion = standings[0].Player
+		}
+
+		return
+	}
+
+	var (
+		number      = b.Rounds[len(b.Rounds)-1].Number
+		winners     []string
+		losers      []string
+		dropped     []string
+		winnersDone bool
+		hadWinners  bool
+	)
+	for _, round := range b.Rounds {
+		if round.Number != number {
+			continue
+		}
+
+		for _, heat := range round.Heats {
+			advancing, eliminated := heat.advancing(b.Advance)
+
+			switch round.Stage {
+			case StageFinal:
+				// A player from the losers bracket winning the final hands the
+				// winners bracket's finalists their first loss, so they race again.
+				if winner := heat.Standings[0].Player; b.Format == DoubleElimination && !slices.Contains(b.Finalists, winner) {
+					reset := []string{}
+					for _, score := range heat.Standings {
+						if score.Player == winner || slices.Contains(b.Finalists, score.Player) {
+							reset = append(reset, score.Player)
+						}
+					}
+
+					b.addRound(number+1, StageReset, reset)
+				} else {
+					b.Champion = winner
+				}
+
+				return
+			case StageReset:
+				b.Champion = heat.Standings[0].Player
+				return
+			case StageWinners:
+				hadWinners = true
+				winners = append(winners, advancing...)
+				dropped = append(dropped, eliminated...)
+				winnersDone = len(round.Heats) == 1
+			case StageLosers:
+				losers = append(losers, advancing...)
+			}
+		}
+	}
+
+	if b.Format == SingleElimination {
+		if winnersDone {
+			b.Champion = winners[0]
+		} else {
+			b.addRound(number+1, StageWinners, winners)
+		}
+
+		return
+	}
+
+	losers = append(append(b.Losers, losers...), dropped...)
+	b.Losers = nil
+
+	if hadWinners {
+		if winnersDone {
+			b.Finalists = winners
+		} else {
+			b.addRound(number+1, StageWinners, winners)
+		}
+	}
+
+	switch {
+	case len(losers) > b.Advance:
+		b.addRound(number+1, StageLosers, losers)
+	case len(b.Finalists) > 0:
+		b.addRound(number+1, StageFinal, append(slices.Clone(b.Finalists), losers...))
+	default:
+		b.Losers = losers
+	}
+}
+
+// Standings returns the total points that each player scored across every
+// heat of the bracket, ordered by heats won and then by points.
+func (b *Bracket) Standings() []Score {
+	var (
+		points = map[string]float64{}
+		wins   = map[string]int{}
+	)
+	for _, round := range b.Rounds {
+		for _, heat := range round.Heats {
+			for i, score := range heat.Standings {
+				points[score.Player] += score.Points
+				if i == 0 && !heat.Bye {
+					wins[score.Player]++
+				}
+			}
+		}
+	}
+
+	standings := make([]Score, len(b.Players))
+	for i, player := range b.Players {
+		standings[i] = Score{Player: player, Points: points[player]}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if wi, wj := wins[standings[i].Player], wins[standings[j].Player]; wi != wj {
+			return wi > wj
+		}
+
+		return standings[i].Points > standings[j].Points
+	})
+
+	return standings
+}
+
+var (
+	bracketFormatNames = map[BracketFormat]string{
+		SingleElimination: "single elimination",
+		DoubleElimination: "double elimination",
+		RoundRobin:        "round robin",
+	}
+)
+
+// EncodeBracketText writes a human-readable rendering of b to w.
+func EncodeBracketText(w io.Writer, b *Bracket) error {
+	if _, err := fmt.Fprintf(w, "%s (%s, heats of %d, top %d advance)\n", b.Name, bracketFormatNames[b.Format], b.HeatSize, b.Advance); err != nil {
+		return err
+	}
+
+	for _, round := range b.Rounds {
+		if _, err := fmt.Fprintf(w, "\nRound %d (%s)\n", round.Number, round.Stage); err != nil {
+			return err
+		}
+
+		for _, heat := range round.Heats {
+			line := strings.Join(heat.Players, ", ") + " (pending)"
+
+			if heat.Bye {
+				line = strings.Join(heat.Players, ", ") + " (bye)"
+			} else if heat.Done {
+				standings := make([]string, len(heat.Standings))
+				for i, score := range heat.Standings {
+					standings[i] = fmt.Sprintf("%d. %s (%g)", i+1, score.Player, score.Points)
+				}
+
+				line = strings.Join(standings, " ")
+			}
+
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", heat.ID, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.Format == RoundRobin {
+		if _, err := fmt.Fprintln(w, "\nStandings"); err != nil {
+			return err
+		}
+
+		for i, score := range b.Standings() {
+			if _, err := fmt.Fprintf(w, "  %d. %s (%g)\n", i+1, score.Player, score.Points); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.Champion != "" {
+		if _, err := fmt.Fprintf(w, "\nChampion: %s\n", b.Champion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package rvglutils_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+// newSession returns a single-race session in which
+// players finish in the given order.
+func newSession(players ...string) *rvglutils.Session {
+	results := make([]rvglutils.Result, len(players))
+	for i, player := range players {
+		results[i] = rvglutils.Result{Position: i + 1, Player: player, Car: "Candy Cane", Finished: true}
+	}
+
+	return &rvglutils.Session{Races: []rvglutils.Race{{Track: "Toys in the Hood 1", Results: results}}}
+}
+
+// race records every pending heat in b, with players finishing in seed order.
+func race(t *testing.T, b *rvglutils.Bracket) {
+	t.Helper()
+
+	for _, heat := range b.PendingHeats() {
+		if err := b.RecordHeat(heat.ID, newSession(heat.Players...)); err != nil {
+			t.Fatalf("record heat %q: %v", heat.ID, err)
+		}
+	}
+}
+
+func TestBracketSingleElimination(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.SingleElimination, []string{"A", "B", "C", "D", "E", "F", "G", "H"})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	if lenHeats := len(b.PendingHeats()); lenHeats != 2 {
+		t.Fatal("unexpected number of heats in 1st round:", lenHeats)
+	}
+
+	race(t, b)
+
+	heats := b.PendingHeats()
+	if len(heats) != 1 {
+		t.Fatal("unexpected number of heats in 2nd round:", len(heats))
+	}
+
+	if len(heats[0].Players) != 4 {
+		t.Fatal("unexpected number of players in final heat:", len(heats[0].Players))
+	}
+
+	if err := b.RecordHeat(heats[0].ID, newSession("D", "C", "B", "A")); err != nil {
+		t.Fatalf("record heat %q: %v", heats[0].ID, err)
+	}
+
+	if b.Champion != "D" {
+		t.Fatal("unexpected champion:", b.Champion)
+	}
+}
+
+func TestBracketDoubleElimination(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.DoubleElimination, []string{"A", "B", "C", "D"}, &rvglutils.NewBracketOpts{HeatSize: 2})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	for i := 0; i < 10 && b.Champion == ""; i++ {
+		race(t, b)
+	}
+
+	if b.Champion != "A" {
+		t.Fatal("unexpected champion:", b.Champion)
+	}
+
+	final := b.Rounds[len(b.Rounds)-1]
+	if final.Stage != rvglutils.StageFinal {
+		t.Fatal("unexpected stage of last round:", final.Stage)
+	}
+
+	if len(final.Heats[0].Players) != 2 || final.Heats[0].Players[1] != "D" {
+		t.Fatal("unexpected players in final:", final.Heats[0].Players)
+	}
+}
+
+func TestBracketDoubleEliminationReset(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.DoubleElimination, []string{"A", "B", "C", "D"}, &rvglutils.NewBracketOpts{HeatSize: 2})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	for i := 0; i < 10 && b.Rounds[len(b.Rounds)-1].Stage != rvglutils.StageFinal; i++ {
+		race(t, b)
+	}
+
+	final := b.PendingHeats()
+	if len(final) != 1 {
+		t.Fatal("unexpected number of heats in final:", len(final))
+	}
+
+	if err := b.RecordHeat(final[0].ID, newSession("D", "A")); err != nil {
+		t.Fatalf("record heat %q: %v", final[0].ID, err)
+	}
+
+	if b.Champion != "" {
+		t.Fatal("unexpected champion before reset:", b.Champion)
+	}
+
+	reset := b.PendingHeats()
+	if len(reset) != 1 || b.Rounds[len(b.Rounds)-1].Stage != rvglutils.StageReset {
+		t.Fatal("expected a reset heat, got", reset)
+	}
+
+	if err := b.RecordHeat(reset[0].ID, newSession("A", "D")); err != nil {
+		t.Fatalf("record heat %q: %v", reset[0].ID, err)
+	}
+
+	if b.Champion != "A" {
+		t.Fatal("unexpected champion:", b.Champion)
+	}
+}
+
+func TestBracketByes(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.SingleElimination, []string{"A", "B", "C", "D", "E"}, &rvglutils.NewBracketOpts{HeatSize: 2})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	if heat := b.Heat("W1-1"); !heat.Done || !heat.Bye {
+		t.Fatal("expected W1-1 to be a bye, got", heat)
+	}
+
+	if heat := b.MatchHeat(newSession("A", "B")); heat != nil && slices.Contains(heat.Players, "A") {
+		t.Fatal("unexpected heat matched:", heat.ID)
+	}
+
+	b, err = rvglutils.NewBracket("test", rvglutils.DoubleElimination, []string{"A", "B", "C", "D", "E", "F"}, &rvglutils.NewBracketOpts{HeatSize: 2})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	for i := 0; i < 20 && b.Champion == ""; i++ {
+		for _, heat := range b.PendingHeats() {
+			if len(heat.Players) < 2 {
+				t.Fatalf("heat %q is pending with players %v", heat.ID, heat.Players)
+			}
+		}
+
+		race(t, b)
+	}
+
+	if b.Champion != "A" {
+		t.Fatal("unexpected champion:", b.Champion)
+	}
+
+	byes := map[string]int{}
+	for _, round := range b.Rounds {
+		for _, heat := range round.Heats {
+			if heat.Bye {
+				byes[heat.Players[0]]++
+				if byes[heat.Players[0]] > 1 {
+					t.Fatalf("player %s got more than one bye", heat.Players[0])
+				}
+			}
+		}
+	}
+}
+
+func TestBracketRoundRobin(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.RoundRobin, []string{"A", "B", "C"})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	if lenHeats := len(b.PendingHeats()); lenHeats != 3 {
+		t.Fatal("unexpected number of heats:", lenHeats)
+	}
+
+	race(t, b)
+
+	if b.Champion != "A" {
+		t.Fatal("unexpected champion:", b.Champion)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := rvglutils.EncodeBracketText(buf, b); err != nil {
+		t.Fatalf("encode bracket: %v", err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte("Champion: A")) {
+		t.Fatal("champion missing from text:", buf.String())
+	}
+}
+
+func TestBracketMatchHeat(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.SingleElimination, []string{"A", "B", "C", "D", "E", "F"}, &rvglutils.NewBracketOpts{HeatSize: 3, Advance: 1})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	heat := b.MatchHeat(newSession("B", "C", "F", "X"))
+	if heat == nil {
+		t.Fatal("no heat matched")
+	}
+
+	if heat.ID != "W1-2" {
+		t.Fatal("unexpected heat matched:", heat.ID)
+	}
+
+	if b.MatchHeat(newSession("A", "B")) != nil {
+		t.Fatal("unexpected heat matched")
+	}
+}
+
+func TestBracketTemplate(t *testing.T) {
+	b, err := rvglutils.NewBracket("test", rvglutils.SingleElimination, []string{"A", "B"})
+	if err != nil {
+		t.Fatalf("new bracket: %v", err)
+	}
+
+	race(t, b)
+
+	tmpl, err := rvglutils.ParseTemplate("{{ bracket .Bracket }}")
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	var (
+		events = &eventSink{}
+		buf    = new(bytes.Buffer)
+	)
+
+	if err := (&rvglutils.EventEmitter{Sink: events}).UpdateSession(t.Context(), newSession("A", "B"), &rvglutils.UpdateSessionOpts{Final: true, Bracket: b}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	final := events.events[len(events.events)-1]
+	if !final.Final() {
+		t.Fatal("expected last event to be final, got", final.Type)
+	}
+
+	if err := tmpl.Execute(buf, final); err != nil {
+		t.Fatalf("execute template: %v", err)
+	}
+
+	expected := new(bytes.Buffer)
+	if err := rvglutils.EncodeBracketText(expected, b); err != nil {
+		t.Fatalf("encode bracket: %v", err)
+	}
+
+	if buf.String() != expected.String() {
+		t.Fatalf("expected %q, got %q", expected, buf)
+	}
+}
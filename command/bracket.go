@@ -0,0 +1,290 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/spf13/cobra"
+)
+
+// validateName checks that name, which comes from user input, names a file
+// directly inside one of rvglsm's directories rather than a path out of it.
+func validateName(name string) error {
+	if name == "" || name == "." || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid name %q", name)
+	}
+
+	return nil
+}
+
+func bracketJSON(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", fmt.Errorf("bracket: %w", err)
+	}
+
+	return filepath.Join(xdg.DataHome, "rvglsm", "brackets", name+".json"), nil
+}
+
+func loadBracket(name string) (*rvglutils.Bracket, error) {
+	path, err := bracketJSON(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load bracket %q: %w", name, err)
+	}
+
+	bracket := &rvglutils.Bracket{}
+	if err := json.Unmarshal(b, bracket); err != nil {
+		return nil, fmt.Errorf("decode bracket %q: %w", name, err)
+	}
+
+	return bracket, nil
+}
+
+func saveBracket(bracket *rvglutils.Bracket) error {
+	name, err := bracketJSON(bracket.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(bracket, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".tmp"
+
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+// recordBracketHeat records session, which was decoded from sessionCSV, as
+// the heat with the given ID in bracket, or as the first pending heat that
+// all of its players raced in if id is empty.
+func recordBracketHeat(bracket *rvglutils.Bracket, id, sessionCSV, races string, session *rvglutils.Session, opts ...rvglutils.ScoreSessionOpt) (*rvglutils.Heat, error) {
+	session, err := sliceRaces(session, races)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		heat := bracket.MatchHeat(session)
+		if heat == nil {
+			return nil, fmt.Errorf("no pending heat in bracket %q for %q", bracket.Name, sessionCSV)
+		}
+
+		id = heat.ID
+	}
+
+	// Only touch the heat once it has been recorded so that
+	// a failure does not leave the bracket half-updated.
+	if err := bracket.RecordHeat(id, session, opts...); err != nil {
+		return nil, err
+	}
+
+	// RecordHeat may have added rounds, so look the heat up again.
+	heat := bracket.Heat(id)
+	heat.Session = sessionCSV
+	heat.Races = races
+
+	return heat, saveBracket(bracket)
+}
+
+// recordSessionBracketHeat records the finished session at sessionCSV
+// as the first pending heat in bracket that all of its players raced in.
+func recordSessionBracketHeat(bracket *rvglutils.Bracket, sessionCSV string, opts ...rvglutils.ScoreSessionOpt) (*rvglutils.Heat, error) {
+	sessions, err := decodeSessionCSVs("", sessionCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordBracketHeat(bracket, "", sessionCSV, "", sessions[0], opts...)
+}
+
+// sliceRaces returns a copy of session with only the races in the
+// 1-indexed, inclusive range "first-last", "first-" or "race".
+func sliceRaces(session *rvglutils.Session, races string) (*rvglutils.Session, error) {
+	if races == "" {
+		return session, nil
+	}
+
+	var (
+		bounds      = strings.SplitN(races, "-", 2)
+		first, last int
+		err         error
+	)
+	if first, err = strconv.Atoi(bounds[0]); err != nil {
+		return nil, fmt.Errorf("parse races %q: %w", races, err)
+	}
+
+	last = first
+	if len(bounds) == 2 {
+		if bounds[1] == "" {
+			last = len(session.Races)
+		} else if last, err = strconv.Atoi(bounds[1]); err != nil {
+			return nil, fmt.Errorf("parse races %q: %w", races, err)
+		}
+	}
+
+	if first < 1 || last > len(session.Races) || first > last {
+		return nil, fmt.Errorf("races %q out of range for session with %d races", races, len(session.Races))
+	}
+
+	sliced := *session
+	sliced.Races = session.Races[first-1 : last]
+
+	return &sliced, nil
+}
+
+func newBracket() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bracket",
+		Short: "Run tournament brackets spanning sessions",
+	}
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.AddCommand(newBracketCreate(), newBracketShow(), newBracketRecord())
+
+	return cmd
+}
+
+func newBracketCreate() *cobra.Command {
+	var (
+		format         string
+		players        []string
+		seeding        string
+		prefPath       string
+		newBracketOpts = &rvglutils.NewBracketOpts{}
+		cmd            = &cobra.Command{
+			Use:   "create name",
+			Short: "Create a bracket",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				path, err := bracketJSON(args[0])
+				if err != nil {
+					return err
+				}
+
+				if _, err := os.Stat(path); err == nil {
+					return fmt.Errorf("bracket %q already exists", args[0])
+				}
+
+				if seeding != "" {
+					if players, err = seedPlayers(prefPath, seeding, players); err != nil {
+						return err
+					}
+				}
+
+				bracket, err := rvglutils.NewBracket(args[0], rvglutils.BracketFormat(format), players, newBracketOpts)
+				if err != nil {
+					return err
+				}
+
+				if err := saveBracket(bracket); err != nil {
+					return err
+				}
+
+				return rvglutils.EncodeBracketText(cmd.OutOrStdout(), bracket)
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().StringVarP(&format, "format", "f", string(rvglutils.SingleElimination), `Bracket format, one of "single", "double" or "round-robin"`)
+	cmd.Flags().StringSliceVarP(&players, "players", "p", nil, "Players in the bracket, ordered by seed")
+	cmd.Flags().StringVar(&seeding, "seeding", "", "Name of the qualifying session to seed players from (default every qualifier if --players is not set)")
+	cmd.Flags().IntVar(&newBracketOpts.HeatSize, "heat-size", 4, "Maximum number of players in each heat")
+	cmd.Flags().IntVarP(&newBracketOpts.Advance, "advance", "n", 0, "Number of players advancing from each heat (default half the heat size)")
+	cmd.Flags().StringVar(&prefPath, "prefpath", "", "RVGL -prefpath to search for the qualifying session in")
+
+	return cmd
+}
+
+func newBracketShow() *cobra.Command {
+	var (
+		output string
+		cmd    = &cobra.Command{
+			Use:   "show name",
+			Short: "Show a bracket",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				bracket, err := loadBracket(args[0])
+				if err != nil {
+					return err
+				}
+
+				if output == "text" {
+					return rvglutils.EncodeBracketText(cmd.OutOrStdout(), bracket)
+				}
+
+				return encode(cmd.OutOrStdout(), output, bracket)
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", `Output format, one of "text", "json" or "yaml"`)
+
+	return cmd
+}
+
+func newBracketRecord() *cobra.Command {
+	var (
+		heat             string
+		races            string
+		scoreSessionOpts = &rvglutils.ScoreSessionOpts{}
+		cmd              = &cobra.Command{
+			Use:   "record name session.csv",
+			Short: "Record a session as a heat in a bracket",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				bracket, err := loadBracket(args[0])
+				if err != nil {
+					return err
+				}
+
+				sessions, err := decodeSessionCSVs("", args[1])
+				if err != nil {
+					return err
+				}
+
+				recorded, err := recordBracketHeat(bracket, heat, args[1], races, sessions[0], scoreSessionOpts)
+				if err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "recorded heat %q\n", recorded.ID)
+
+				return rvglutils.EncodeBracketText(cmd.OutOrStdout(), bracket)
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().StringVar(&heat, "heat", "", "ID of the heat to record (default the first pending heat that all of its players raced in)")
+	cmd.Flags().StringVar(&races, "races", "", `Races of the session that make up the heat, e.g. "1-3"`)
+	cmd.Flags().IntVar(&scoreSessionOpts.ExtraPointsPerRace, "extra-pts-per-race", 0, "Extra points to award per race")
+	cmd.Flags().StringToIntVarP(&scoreSessionOpts.Handicap, "handicap", "H", nil, "Handicap to apply")
+
+	return cmd
+}
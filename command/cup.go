@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"sigs.k8s.io/yaml"
+)
+
+// loadCup reads a cup from the file at the given path or, failing that,
+// from the file of the given name in rvglsm's cups config directory.
+func loadCup(name string) (*rvglutils.Cup, error) {
+	candidates := []string{name}
+	if validateName(name) == nil {
+		for _, ext := range []string{".yaml", ".yml", ".json"} {
+			candidates = append(candidates, filepath.Join(xdg.ConfigHome, "rvglsm", "cups", name+ext))
+		}
+	}
+
+	for _, candidate := range candidates {
+		b, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		cup := &rvglutils.Cup{}
+		if err := yaml.Unmarshal(b, cup); err != nil {
+			return nil, fmt.Errorf("decode cup %q: %w", candidate, err)
+		}
+
+		if cup.Name == "" {
+			cup.Name = strings.TrimSuffix(filepath.Base(candidate), filepath.Ext(candidate))
+		}
+
+		return cup, nil
+	}
+
+	return nil, fmt.Errorf("find cup %q", name)
+}
+
+// cupSink reports a session's progress through a cup to a writer
+// before passing each update on to the wrapped sink.
+type cupSink struct {
+	rvglutils.SinkWrapper
+	Cup    *rvglutils.Cup
+	Writer io.Writer
+
+	warned int
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *cupSink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	progress := s.Cup.Progress(session, o.ScoreSessionOpts)
+
+	for _, mismatch := range progress.Mismatches[min(s.warned, len(progress.Mismatches)):] {
+		if mismatch.TrackMismatch() {
+			_, _ = fmt.Fprintf(s.Writer, "warning: race %d/%d of %s was %q, expected %q\n", mismatch.Race, len(s.Cup.Tracks), s.Cup.Name, mismatch.Actual, mismatch.Expected)
+		}
+
+		if mismatch.LapsMismatch() {
+			_, _ = fmt.Fprintf(s.Writer, "warning: race %d/%d of %s expects %d laps, but the session is set to %d\n", mismatch.Race, len(s.Cup.Tracks), s.Cup.Name, mismatch.ExpectedLaps, mismatch.SessionLaps)
+		}
+	}
+	s.warned = len(progress.Mismatches)
+
+	_, _ = fmt.Fprintln(s.Writer, progress)
+
+	return s.Sink.UpdateSession(ctx, session, opts...)
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/spf13/cobra"
+)
+
+func newHandicap() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "handicap",
+		Short: "Work with handicaps",
+	}
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.AddCommand(newHandicapSuggest())
+
+	return cmd
+}
+
+func newHandicapSuggest() *cobra.Command {
+	var (
+		prefPath            string
+		output              string
+		scoreSessionOpts    = &rvglutils.ScoreSessionOpts{}
+		suggestHandicapOpts = &rvglutils.SuggestHandicapOpts{ScoreSessionOpts: scoreSessionOpts}
+		cmd                 = &cobra.Command{
+			Use:   "suggest [session.csv...]",
+			Short: "Suggest handicaps from historical sessions",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				sessions, err := decodeSessionCSVs(prefPath, args...)
+				if err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "rating players from %d sessions\n", len(sessions))
+
+				return encodeHandicap(cmd.OutOrStdout(), output, rvglutils.SuggestHandicap(sessions, suggestHandicapOpts))
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().StringVarP(&output, "output", "o", "flag", `Output format, one of "flag", "json" or "yaml"`)
+	cmd.Flags().IntVar(&suggestHandicapOpts.Races, "races", 0, "Number of races the handicap should cover (default the average races per session)")
+	cmd.Flags().BoolVar(&scoreSessionOpts.IncludeAI, "include-ai", false, "Rate AI players")
+	cmd.Flags().IntVar(&scoreSessionOpts.ExtraPointsPerRace, "extra-pts-per-race", 0, "Extra points to award per race")
+	cmd.Flags().CountVarP(&scoreSessionOpts.ExcludeRaces, "exclude", "x", "Number of races at the beginning of each session to exclude")
+	cmd.Flags().StringVar(&prefPath, "prefpath", "", "RVGL -prefpath to search for sessions in")
+
+	return cmd
+}
+
+// encodeHandicap writes handicap in the given format. The "flag" format
+// can be passed directly to --handicap and the "json" and "yaml" formats
+// can be written to the --handicaps file.
+func encodeHandicap(w io.Writer, format string, handicap map[string]int) error {
+	switch format {
+	case "flag":
+		var (
+			players = make([]string, 0, len(handicap))
+			pairs   = make([]string, 0, len(handicap))
+		)
+		for player := range handicap {
+			players = append(players, player)
+		}
+		slices.Sort(players)
+
+		for _, player := range players {
+			// --handicap splits on every "," and then on the first "="
+			// without honoring any quoting, so such names cannot be passed.
+			if strings.ContainsAny(player, ",=") {
+				return fmt.Errorf(`player %q cannot be passed to --handicap, use --output json or yaml for the --handicaps file instead`, player)
+			}
+
+			pairs = append(pairs, fmt.Sprintf("%s=%d", player, handicap[player]))
+		}
+
+		_, err := fmt.Fprintln(w, strings.Join(pairs, ","))
+		return err
+	case "json", "yaml":
+		return encode(w, format, handicap)
+	}
+
+	return fmt.Errorf(`invalid output format %q, expected "flag", "json" or "yaml"`, format)
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -14,11 +16,25 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
 	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/metrics"
 	"github.com/frantjc/rvgl-utils/sinks/discord"
+	_ "github.com/frantjc/rvgl-utils/sinks/exec"
+	_ "github.com/frantjc/rvgl-utils/sinks/file"
+	_ "github.com/frantjc/rvgl-utils/sinks/irc"
+	_ "github.com/frantjc/rvgl-utils/sinks/matrix"
+	_ "github.com/frantjc/rvgl-utils/sinks/mqtt"
+	_ "github.com/frantjc/rvgl-utils/sinks/overlay"
+	"github.com/frantjc/rvgl-utils/sinks/plugin"
+	"github.com/frantjc/rvgl-utils/sinks/slack"
+	_ "github.com/frantjc/rvgl-utils/sinks/smtp"
 	"github.com/frantjc/rvgl-utils/sinks/stdout"
+	_ "github.com/frantjc/rvgl-utils/sinks/telegram"
+	"github.com/frantjc/rvgl-utils/sinks/webhook"
+	_ "github.com/frantjc/rvgl-utils/sinks/websocket"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
@@ -43,6 +59,56 @@ func updateSession(ctx context.Context, sink rvglutils.Sink, sessionCSV string,
 	return nil
 }
 
+// redactSinkURL returns u without anything that is likely to be a secret,
+// so that it can identify a sink in errors: its user info and query and,
+// since webhook URLs carry their tokens in it, the path of http(s) URLs.
+func redactSinkURL(u *url.URL) string {
+	redacted := &url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	switch u.Scheme {
+	case "http", "https", webhook.Scheme, webhook.SchemeTLS:
+	default:
+		redacted.Path = u.Path
+		redacted.Fragment = u.Fragment
+	}
+
+	return redacted.String()
+}
+
+// decodeSessionCSVs decodes each of the given session .csvs, or every
+// session .csv that can be resolved from prefPath if none are given.
+func decodeSessionCSVs(prefPath string, names ...string) ([]*rvglutils.Session, error) {
+	if len(names) == 0 {
+		resolveSessionCSVOpts := &rvglutils.ResolveSessionCSVOpts{}
+		if prefPath != "" {
+			resolveSessionCSVOpts.PathList = filepath.Join(prefPath, "profiles")
+		}
+
+		var err error
+		names, err = rvglutils.ResolveSessionCSVs(resolveSessionCSVOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sessions := make([]*rvglutils.Session, len(names))
+
+	for i, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open %q: %w", name, err)
+		}
+
+		sessions[i], err = rvglutils.DecodeSessionCSV(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode %q: %w", name, err)
+		}
+	}
+
+	return sessions, nil
+}
+
 func init() {
 	rvglutils.RegisterSink(&httpSinkOpener{}, "http", "https")
 }
@@ -58,9 +124,11 @@ func (h *httpSinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink,
 	}
 
 	switch u.Hostname() {
-	case "discordapp.com":
+	case "discordapp.com", "discord.com":
+	case "hooks.slack.com":
+		return slack.Open(ctx, u)
 	default:
-		return nil, fmt.Errorf(`invalid host %q, expected "discordapp.com"`, u.Hostname())
+		return nil, fmt.Errorf("unsupported host %q, use a %s:// or %s:// URL for a generic webhook", u.Hostname(), webhook.Scheme, webhook.SchemeTLS)
 	}
 
 	var (
@@ -75,11 +143,20 @@ func (h *httpSinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink,
 		return nil, fmt.Errorf("invalid discord webhook URL path: %q", u.Path)
 	}
 
-	return &discord.Sink{
+	s := &discord.Sink{
 		WebhookID: matches[1],
 		Token:     matches[2],
 		MessageID: messageID,
-	}, nil
+	}
+
+	if text := u.Query().Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
 }
 
 // NewRVGLSM returns the command for `rvglsm`.
@@ -88,9 +165,17 @@ func NewRVGLSM() *cobra.Command {
 		prefPath              string
 		resolveSessionCSVOpts = &rvglutils.ResolveSessionCSVOpts{}
 		scoreSessionOpts      = &rvglutils.ScoreSessionOpts{}
+		updateSessionOpts     = &rvglutils.UpdateSessionOpts{ScoreSessionOpts: scoreSessionOpts}
 		laps                  int
-		sinkURL               string
+		sinkURLs              []string
 		multipliers           string
+		handicaps             string
+		plugins               string
+		metricsAddr           string
+		templateText          string
+		bracketName           string
+		cupName               string
+		qualifying            string
 		cmd                   = &cobra.Command{
 			Use:           "rvglsm",
 			SilenceErrors: true,
@@ -161,8 +246,18 @@ func NewRVGLSM() *cobra.Command {
 					return err
 				}
 
-				sessionCSV, err := rvglutils.ResolveSessionCSV(resolveSessionCSVOpts)
-				if err != nil {
+				if handicapsFile, err := os.Open(handicaps); err == nil {
+					defer handicapsFile.Close() //nolint:errcheck
+
+					b, err := io.ReadAll(handicapsFile)
+					if err != nil {
+						return err
+					}
+
+					if err := yaml.Unmarshal(b, &scoreSessionOpts.Handicap); err != nil {
+						return err
+					}
+				} else if !errors.Is(err, os.ErrNotExist) {
 					return err
 				}
 
@@ -170,20 +265,129 @@ func NewRVGLSM() *cobra.Command {
 					resolveSessionCSVOpts.PathList = filepath.Join(prefPath, "profiles")
 				}
 
+				if templateText != "" {
+					tmpl, err := rvglutils.ParseTemplate(templateText)
+					if err != nil {
+						return fmt.Errorf("parse template: %w", err)
+					}
+
+					updateSessionOpts.Template = tmpl
+				}
+
+				sessionCSV, err := rvglutils.ResolveSessionCSV(resolveSessionCSVOpts)
+				if err != nil {
+					return err
+				}
+
+				if sessionCSV, err = filepath.Abs(sessionCSV); err != nil {
+					return err
+				}
+
 				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "resolved session %q\n", sessionCSV)
 
+				index, err := loadSessionIndex()
+				if err != nil {
+					return err
+				}
+
+				if qualifying != "" {
+					qualifyingCSV, err := resolveSessionCSV(prefPath, qualifying)
+					if err != nil {
+						return err
+					}
+
+					if err := linkQualifyingSession(index, sessionCSV, qualifyingCSV, &rvglutils.ScoreSessionOpts{IncludeAI: scoreSessionOpts.IncludeAI}); err != nil {
+						return err
+					}
+
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "seeded session from qualifying session %q\n", qualifyingCSV)
+				}
+
+				scoreSessionOpts.Seeding = index.Seeding(sessionCSV)
+				updateSessionOpts.SessionCSV = sessionCSV
+				updateSessionOpts.State = &rvglutils.FileStateStore{Path: stateJSON()}
+
 				var (
 					ctx                 = cmd.Context()
 					sink rvglutils.Sink = &stdout.Sink{Writer: cmd.OutOrStdout()}
 				)
 
-				if sinkURL != "" {
-					sink, err = rvglutils.OpenSink(ctx, sinkURL)
+				var m *metrics.Metrics
+				if metricsAddr != "" {
+					m = metrics.New()
+
+					l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", metricsAddr)
 					if err != nil {
-						return fmt.Errorf("open sink: %w", err)
+						return fmt.Errorf("serve metrics: %w", err)
 					}
 
-					_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "opened sink")
+					mux := http.NewServeMux()
+					mux.Handle("/metrics", m.Handler())
+
+					srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+					defer srv.Close() //nolint:errcheck
+
+					go srv.Serve(l) //nolint:errcheck
+
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "serving metrics on %s/metrics\n", l.Addr())
+
+					sink = m.InstrumentSink("0", "stdout", sink)
+				}
+
+				if schemes, err := plugin.Discover(plugins); err != nil {
+					return fmt.Errorf("discover plugins: %w", err)
+				} else if len(schemes) > 0 {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "discovered plugins for %s\n", strings.Join(schemes, ", "))
+				}
+
+				if len(sinkURLs) > 0 {
+					sinks := make(rvglutils.MultiSink, len(sinkURLs))
+
+					for i, sinkURL := range sinkURLs {
+						sinks[i], err = rvglutils.OpenSink(ctx, sinkURL)
+						if err != nil {
+							_ = rvglutils.CloseSink(ctx, sinks[:i])
+							return fmt.Errorf("open sink %d: %w", i, err)
+						}
+
+						if u, err := url.Parse(sinkURL); err == nil {
+							if m != nil {
+								sinks[i] = m.InstrumentSink(strconv.Itoa(i), u.Scheme, sinks[i])
+							}
+
+							sinks[i] = &rvglutils.URLSink{Sink: sinks[i], URL: redactSinkURL(u)}
+						}
+
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "opened sink %d\n", i)
+					}
+
+					sink = sinks
+					if len(sinks) == 1 {
+						sink = sinks[0]
+					}
+				}
+
+				// sink is read when this runs, so it closes whatever the
+				// opened sinks end up wrapped in, even if a later step fails.
+				defer func() {
+					if err := rvglutils.CloseSink(context.WithoutCancel(ctx), sink); err != nil {
+						_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err)
+					}
+				}()
+
+				sink = &rvglutils.EventEmitter{Sink: sink}
+
+				if m != nil {
+					sink = m.Sink(sink)
+				}
+
+				if cupName != "" {
+					cup, err := loadCup(cupName)
+					if err != nil {
+						return err
+					}
+
+					sink = &cupSink{SinkWrapper: rvglutils.SinkWrapper{Sink: sink}, Cup: cup, Writer: cmd.ErrOrStderr()}
 				}
 
 				watcher, err := fsnotify.NewWatcher()
@@ -206,18 +410,54 @@ func NewRVGLSM() *cobra.Command {
 
 				go func() {
 					for event := range watcher.Events {
+						if m != nil && event.Name == sessionCSV {
+							m.ObserveWatcherEvent(event.Op.String())
+						}
+
 						if event.Name == sessionCSV {
-							if err := updateSession(ctx, sink, sessionCSV, &rvglutils.UpdateSessionOpts{ScoreSessionOpts: scoreSessionOpts}); err != nil {
+							if err := updateSession(ctx, sink, sessionCSV, updateSessionOpts); err != nil {
 								watcher.Errors <- err
 							}
 						}
 					}
 				}()
 
-				if err := updateSession(ctx, sink, sessionCSV, &rvglutils.UpdateSessionOpts{ScoreSessionOpts: scoreSessionOpts}); err != nil {
-					return err
+				var bracket *rvglutils.Bracket
+				if bracketName != "" {
+					if bracket, err = loadBracket(bracketName); err != nil {
+						return err
+					}
+				}
+
+				if err := updateSession(ctx, sink, sessionCSV, updateSessionOpts); err != nil {
+					// A MultiSink's Sinks fail independently,
+					// so keep going for the sake of the others.
+					if sinkErr := (&rvglutils.SinkError{}); !errors.As(err, &sinkErr) {
+						return err
+					}
+
+					_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err)
 				}
-				defer updateSession(context.WithoutCancel(ctx), sink, sessionCSV, &rvglutils.UpdateSessionOpts{Final: true, ScoreSessionOpts: scoreSessionOpts}) //nolint:errcheck
+				defer func() {
+					final := &rvglutils.UpdateSessionOpts{Final: true}
+
+					// Record the finished session in the bracket first so that
+					// the final update's Event carries it. Sinks keep sending
+					// their usual final standings. Those with a template can
+					// render the bracket along with them with {{ bracket .Bracket }}.
+					if bracket != nil {
+						if heat, err := recordSessionBracketHeat(bracket, sessionCSV, scoreSessionOpts); err != nil {
+							_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err)
+						} else {
+							_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "recorded heat %q in bracket %q\n", heat.ID, bracketName)
+							_ = rvglutils.EncodeBracketText(cmd.ErrOrStderr(), bracket)
+
+							final.Bracket = bracket
+						}
+					}
+
+					_ = updateSession(context.WithoutCancel(ctx), sink, sessionCSV, updateSessionOpts, final)
+				}()
 
 				<-ctx.Done()
 				return ctx.Err()
@@ -229,19 +469,29 @@ func NewRVGLSM() *cobra.Command {
 	cmd.Flags().Bool("version", false, "Version for "+cmd.Name())
 	cmd.SetVersionTemplate("{{ .Name }}{{ .Version }} " + runtime.Version() + "\n")
 
-	cmd.Flags().StringVarP(&sinkURL, "sink", "s", "", "URL of the sink to send updates to (e.g. a Discord webhook URL)")
+	cmd.Flags().StringArrayVarP(&sinkURLs, "sink", "s", nil, "URL of a sink to send updates to (e.g. a Discord webhook URL), can be repeated")
+	cmd.Flags().StringVar(&templateText, "template", "", "Go text/template for text-based sinks to render updates with, or @path to read it from a file")
 	cmd.Flags().StringVar(&resolveSessionCSVOpts.Name, "session", "", "Name of the session to resolve instead of using the latest one")
 	cmd.Flags().BoolVar(&scoreSessionOpts.IncludeAI, "include-ai", false, "Score AI players")
 	cmd.Flags().IntVar(&scoreSessionOpts.Interval, "interval", 0, "Interval at which to reset points")
 	cmd.Flags().IntVar(&scoreSessionOpts.ExtraPointsPerRace, "extra-pts-per-race", 0, "Extra points to award per race")
 	cmd.Flags().CountVarP(&scoreSessionOpts.ExcludeRaces, "exclude", "x", "Number of races at the beginning of the session to exclude")
 	cmd.Flags().StringToIntVarP(&scoreSessionOpts.Handicap, "handicap", "H", nil, "Handicap to apply")
+	cmd.Flags().StringVar(&metricsAddr, "metrics", "", "Address to serve Prometheus metrics on at /metrics, e.g. \":9090\"")
+	cmd.Flags().StringVar(&plugins, "plugins", filepath.Join(xdg.ConfigHome, cmd.Name(), "plugins"), "Directory of sink plugins")
+	cmd.Flags().StringVar(&handicaps, "handicaps", filepath.Join(xdg.ConfigHome, cmd.Name(), "handicaps.json"), "Handicaps to apply")
 	cmd.Flags().StringVar(&prefPath, "prefpath", "", "RVGL -prefpath to search for the session in")
 	cmd.Flags().StringVarP(&multipliers, "multipliers", "m", filepath.Join(xdg.ConfigHome, cmd.Name(), "multipliers.json"), "Multipliers to apply")
 	cmd.Flags().VarP(newStringToFloat64Value(nil, &scoreSessionOpts.Multipliers), "multiplier", "M", "Multiplier to apply")
 
+	cmd.Flags().StringVar(&qualifying, "qualifying", "", "Name of the qualifying session to seed the session from to break ties")
+	cmd.Flags().StringVar(&cupName, "cup", "", "Path or name of the cup to track the session's progress through")
+	cmd.Flags().StringVar(&bracketName, "bracket", "", "Name of the bracket to record the session in as a heat when it finishes")
+
 	cmd.Flags().IntVar(&laps, "laps", 0, "Set NLaps in default profile.ini and exit")
 
+	cmd.AddCommand(newHandicap(), newTeams(), newBracket(), newSession())
+
 	return cmd
 }
 
@@ -0,0 +1,232 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/adrg/xdg"
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/spf13/cobra"
+)
+
+func sessionIndexJSON() string {
+	return filepath.Join(xdg.DataHome, "rvglsm", "sessions.json")
+}
+
+// stateJSON is where sinks keep state across runs of rvglsm.
+func stateJSON() string {
+	return filepath.Join(xdg.StateHome, "rvglsm", "state.json")
+}
+
+func loadSessionIndex() (rvglutils.SessionIndex, error) {
+	index := rvglutils.SessionIndex{}
+
+	b, err := os.ReadFile(sessionIndexJSON())
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("load session index: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("decode session index: %w", err)
+	}
+
+	return index, nil
+}
+
+func saveSessionIndex(index rvglutils.SessionIndex) error {
+	name := sessionIndexJSON()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".tmp"
+
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+// resolveSessionCSV resolves the session .csv with the given name, or the
+// latest one if name is empty, from prefPath as an absolute path.
+func resolveSessionCSV(prefPath, name string) (string, error) {
+	resolveSessionCSVOpts := &rvglutils.ResolveSessionCSVOpts{Name: name}
+	if prefPath != "" {
+		resolveSessionCSVOpts.PathList = filepath.Join(prefPath, "profiles")
+	}
+
+	sessionCSV, err := rvglutils.ResolveSessionCSV(resolveSessionCSVOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(sessionCSV)
+}
+
+// qualifySession marks the session with the given .csv as a
+// qualifying session and stores its seeding in index.
+func qualifySession(index rvglutils.SessionIndex, sessionCSV string, opts ...rvglutils.ScoreSessionOpt) ([]string, error) {
+	sessions, err := decodeSessionCSVs("", sessionCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	seeding := rvglutils.Qualify(sessions[0], opts...)
+
+	index[sessionCSV] = &rvglutils.SessionRecord{
+		Role:    rvglutils.RoleQualifying,
+		Seeding: seeding,
+	}
+
+	return seeding, nil
+}
+
+// linkQualifyingSession marks the session with the given .csv as a race session
+// seeded by qualifyingCSV, qualifying that session first if need be.
+func linkQualifyingSession(index rvglutils.SessionIndex, sessionCSV, qualifyingCSV string, opts ...rvglutils.ScoreSessionOpt) error {
+	if sessionCSV == qualifyingCSV {
+		return fmt.Errorf("session %q cannot be seeded by itself", sessionCSV)
+	}
+
+	if record, ok := index[qualifyingCSV]; !ok || record.Role != rvglutils.RoleQualifying {
+		if _, err := qualifySession(index, qualifyingCSV, opts...); err != nil {
+			return err
+		}
+	}
+
+	index[sessionCSV] = &rvglutils.SessionRecord{
+		Role:       rvglutils.RoleRace,
+		Qualifying: qualifyingCSV,
+	}
+
+	return saveSessionIndex(index)
+}
+
+// seedPlayers orders players by the seeding of the given qualifying session.
+// Players that did not qualify go last. If players is empty, every player
+// that qualified is returned.
+func seedPlayers(prefPath, qualifying string, players []string) ([]string, error) {
+	qualifyingCSV, err := resolveSessionCSV(prefPath, qualifying)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := loadSessionIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	seeding := index.Seeding(qualifyingCSV)
+	if seeding == nil {
+		if seeding, err = qualifySession(index, qualifyingCSV); err != nil {
+			return nil, err
+		}
+
+		if err := saveSessionIndex(index); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(players) == 0 {
+		return seeding, nil
+	}
+
+	seeded := []string{}
+	for _, player := range seeding {
+		for _, p := range players {
+			if strings.EqualFold(p, player) {
+				seeded = append(seeded, p)
+			}
+		}
+	}
+
+	for _, p := range players {
+		if !slices.Contains(seeded, p) {
+			seeded = append(seeded, p)
+		}
+	}
+
+	return seeded, nil
+}
+
+func newSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Work with sessions",
+	}
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.AddCommand(newSessionQualify())
+
+	return cmd
+}
+
+func newSessionQualify() *cobra.Command {
+	var (
+		prefPath         string
+		scoreSessionOpts = &rvglutils.ScoreSessionOpts{}
+		cmd              = &cobra.Command{
+			Use:   "qualify [session.csv]",
+			Short: "Mark a session as qualifying and store its seeding",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				name := ""
+				if len(args) > 0 {
+					name = args[0]
+				}
+
+				sessionCSV, err := resolveSessionCSV(prefPath, name)
+				if err != nil {
+					return err
+				}
+
+				index, err := loadSessionIndex()
+				if err != nil {
+					return err
+				}
+
+				seeding, err := qualifySession(index, sessionCSV, scoreSessionOpts)
+				if err != nil {
+					return err
+				}
+
+				if err := saveSessionIndex(index); err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "qualified session %q\n", sessionCSV)
+
+				for i, player := range seeding {
+					if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%d. %s\n", i+1, player); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().BoolVar(&scoreSessionOpts.IncludeAI, "include-ai", false, "Seed AI players")
+	cmd.Flags().CountVarP(&scoreSessionOpts.ExcludeRaces, "exclude", "x", "Number of races at the beginning of the session to exclude")
+	cmd.Flags().StringVar(&prefPath, "prefpath", "", "RVGL -prefpath to search for the session in")
+
+	return cmd
+}
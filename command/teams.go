@@ -0,0 +1,80 @@
+package command
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/spf13/cobra"
+)
+
+func newTeams() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "teams",
+		Short: "Work with teams",
+	}
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.AddCommand(newTeamsDraft())
+
+	return cmd
+}
+
+func newTeamsDraft() *cobra.Command {
+	var (
+		prefPath         string
+		output           string
+		players          []string
+		scoreSessionOpts = &rvglutils.ScoreSessionOpts{}
+		draftTeamsOpts   = &rvglutils.DraftTeamsOpts{ScoreSessionOpts: scoreSessionOpts}
+		cmd              = &cobra.Command{
+			Use:   "draft [session.csv...]",
+			Short: "Draft balanced teams from historical sessions",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				sessions, err := decodeSessionCSVs(prefPath, args...)
+				if err != nil {
+					return err
+				}
+
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "rating players from %d sessions\n", len(sessions))
+
+				teams, err := rvglutils.DraftTeams(players, sessions, draftTeamsOpts)
+				if err != nil {
+					return err
+				}
+
+				if output == "text" {
+					// Order "Team 2" before "Team 10".
+					names := slices.SortedFunc(maps.Keys(teams), func(a, b string) int {
+						return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+					})
+					for _, name := range names {
+						if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", name, strings.Join(teams[name], ", ")); err != nil {
+							return err
+						}
+					}
+
+					return nil
+				}
+
+				return encode(cmd.OutOrStdout(), output, teams)
+			},
+		}
+	)
+
+	cmd.Flags().BoolP("help", "h", false, "Help for "+cmd.Name())
+
+	cmd.Flags().StringSliceVarP(&players, "players", "p", nil, "Players to draft")
+	cmd.Flags().IntVarP(&draftTeamsOpts.Teams, "teams", "n", 2, "Number of teams to draft")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", `Output format, one of "json", "yaml" or "text"`)
+	cmd.Flags().IntVar(&scoreSessionOpts.ExtraPointsPerRace, "extra-pts-per-race", 0, "Extra points to award per race")
+	cmd.Flags().CountVarP(&scoreSessionOpts.ExcludeRaces, "exclude", "x", "Number of races at the beginning of each session to exclude")
+	cmd.Flags().StringVar(&prefPath, "prefpath", "", "RVGL -prefpath to search for sessions in")
+	_ = cmd.MarkFlagRequired("players")
+
+	return cmd
+}
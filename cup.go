@@ -0,0 +1,118 @@
+package rvglutils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cup is an ordered list of tracks to be raced in a session.
+type Cup struct {
+	Name   string     `json:"name"`
+	Tracks []CupTrack `json:"tracks"`
+}
+
+type CupTrack struct {
+	Track string `json:"track"`
+	Laps  int    `json:"laps,omitempty"`
+}
+
+// CupProgress is how far through a Cup a session is.
+type CupProgress struct {
+	Cup *Cup
+	// Completed is the number of the cup's races that the session has finished.
+	Completed int
+	// Mismatches are the cup's races that were raced on the wrong track
+	// or in a session set to a different number of laps than the cup sets.
+	Mismatches []CupMismatch
+}
+
+type CupMismatch struct {
+	// Race is the 1-indexed number of the race within the cup.
+	Race     int
+	Expected string
+	Actual   string
+	// ExpectedLaps and SessionLaps differ if the session was set to a
+	// different number of laps than the cup sets for the race. Session
+	// logs only record the session's lap setting, not each race's.
+	ExpectedLaps int
+	SessionLaps  int
+}
+
+// TrackMismatch reports whether the race was raced on the wrong track.
+func (m *CupMismatch) TrackMismatch() bool {
+	return !strings.EqualFold(strings.TrimSpace(m.Actual), strings.TrimSpace(m.Expected))
+}
+
+// LapsMismatch reports whether the session was set to the wrong number of laps for the race.
+func (m *CupMismatch) LapsMismatch() bool {
+	return m.SessionLaps != m.ExpectedLaps
+}
+
+// Progress returns how far through c the given session is. The cup is
+// considered to begin after the races excluded by ExcludeRaces.
+func (c *Cup) Progress(session *Session, opts ...ScoreSessionOpt) *CupProgress {
+	var (
+		o = newScoreSessionOpts(opts...)
+		p = &CupProgress{Cup: c}
+	)
+
+	if session == nil {
+		return p
+	}
+
+	races := session.Races[min(max(o.ExcludeRaces, 0), len(session.Races)):]
+
+	for i, race := range races {
+		if i >= len(c.Tracks) {
+			break
+		}
+
+		mismatch := CupMismatch{
+			Race:     i + 1,
+			Expected: c.Tracks[i].Track,
+			Actual:   race.Track,
+		}
+
+		// Laps are only compared if both the cup and the session say.
+		if c.Tracks[i].Laps > 0 && session.Laps > 0 {
+			mismatch.ExpectedLaps = c.Tracks[i].Laps
+			mismatch.SessionLaps = session.Laps
+		}
+
+		if mismatch.TrackMismatch() || mismatch.LapsMismatch() {
+			p.Mismatches = append(p.Mismatches, mismatch)
+		}
+
+		p.Completed++
+	}
+
+	return p
+}
+
+// Next returns the next track to be raced in the cup, or nil if the cup is complete.
+func (p *CupProgress) Next() *CupTrack {
+	if p.Completed < len(p.Cup.Tracks) {
+		return &p.Cup.Tracks[p.Completed]
+	}
+
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (p *CupProgress) String() string {
+	next := p.Next()
+	if next == nil {
+		if p.Cup.Name == "" {
+			return "Cup complete"
+		}
+
+		return fmt.Sprintf("%s complete", p.Cup.Name)
+	}
+
+	s := fmt.Sprintf("Race %d/%d: %s", p.Completed+1, len(p.Cup.Tracks), next.Track)
+	if next.Laps > 0 {
+		s += fmt.Sprintf(" (%d laps)", next.Laps)
+	}
+
+	return s
+}
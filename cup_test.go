@@ -0,0 +1,88 @@
+package rvglutils_test
+
+import (
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestCupProgress(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		cup = &rvglutils.Cup{
+			Tracks: []rvglutils.CupTrack{
+				{Track: "Downhill Jam (THUG2)"},
+				{Track: "Downhill Jam (THUG2)"},
+				{Track: "Toys in the Hood 1"},
+				{Track: "Downhill Jam (THUG2)"},
+				{Track: "Toys in the Hood 1", Laps: 3},
+				{Track: "Toys in the Hood 2"},
+			},
+		}
+		progress = cup.Progress(session)
+	)
+
+	if progress.Completed != 4 {
+		t.Fatal("unexpected number of completed races:", progress.Completed)
+	}
+
+	if len(progress.Mismatches) != 1 || progress.Mismatches[0].Race != 3 {
+		t.Fatal("unexpected mismatches:", progress.Mismatches)
+	}
+
+	if s := progress.String(); s != "Race 5/6: Toys in the Hood 1 (3 laps)" {
+		t.Fatal("unexpected progress:", s)
+	}
+
+	if progress = cup.Progress(session, &rvglutils.ScoreSessionOpts{ExcludeRaces: 2}); progress.Completed != 2 {
+		t.Fatal("unexpected number of completed races:", progress.Completed)
+	}
+}
+
+func TestCupProgressLaps(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		cup = &rvglutils.Cup{
+			Tracks: []rvglutils.CupTrack{
+				{Track: "Downhill Jam (THUG2)", Laps: session.Laps},
+				{Track: "Downhill Jam (THUG2)", Laps: session.Laps + 1},
+			},
+		}
+		progress = cup.Progress(session)
+	)
+
+	if len(progress.Mismatches) != 1 {
+		t.Fatal("unexpected mismatches:", progress.Mismatches)
+	}
+
+	mismatch := progress.Mismatches[0]
+	if mismatch.Race != 2 || mismatch.TrackMismatch() || !mismatch.LapsMismatch() || mismatch.SessionLaps != session.Laps {
+		t.Fatal("unexpected mismatch:", mismatch)
+	}
+}
+
+func TestCupProgressComplete(t *testing.T) {
+	session := testdata.Session(t)
+
+	cup := &rvglutils.Cup{
+		Name:   "Downhill Cup",
+		Tracks: []rvglutils.CupTrack{{Track: "Downhill Jam (THUG2)"}, {Track: "downhill jam (thug2)"}},
+	}
+
+	progress := cup.Progress(session)
+
+	if len(progress.Mismatches) != 0 {
+		t.Fatal("unexpected mismatches:", progress.Mismatches)
+	}
+
+	if progress.Next() != nil {
+		t.Fatal("unexpected next track:", progress.Next().Track)
+	}
+
+	if s := progress.String(); s != "Downhill Cup complete" {
+		t.Fatal("unexpected progress:", s)
+	}
+}
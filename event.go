@@ -0,0 +1,235 @@
+package rvglutils
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	// EventSessionStarted is sent the first time that a session is seen.
+	EventSessionStarted EventType = "SessionStarted"
+	// EventRaceCompleted is sent for each race that finishes after that.
+	EventRaceCompleted EventType = "RaceCompleted"
+	// EventSessionFinal is sent once the session is over.
+	EventSessionFinal EventType = "SessionFinal"
+	// EventSessionUpdated is used when a session is updated directly
+	// through Sink.UpdateSession instead, or when the results of its
+	// latest race change after that race was already sent, as they do
+	// while the session log is still being written.
+	EventSessionUpdated EventType = "SessionUpdated"
+)
+
+// Standing is a player's Score along with how it changed with the latest race.
+type Standing struct {
+	Score
+	Position int `json:"position"`
+	// PreviousPosition is the player's position before the latest race,
+	// or 0 if they had no score before it.
+	PreviousPosition int `json:"previousPosition"`
+	// Change is the number of points the player gained in the latest race.
+	Change float64 `json:"change"`
+}
+
+// Standings returns the scores for the given session along with
+// how each of them changed with the session's last race.
+func Standings(session *Session, opts ...ScoreSessionOpt) []Standing {
+	var (
+		scores         = ScoreSession(session, opts...)
+		previous       = map[string]int{}
+		previousPoints = map[string]float64{}
+	)
+	if session != nil && len(session.Races) > 0 {
+		before := *session
+		before.Races = session.Races[:len(session.Races)-1]
+
+		for i, score := range ScoreSession(&before, opts...) {
+			previous[score.Player] = i + 1
+			previousPoints[score.Player] = score.Points
+		}
+	}
+
+	standings := make([]Standing, len(scores))
+	for i, score := range scores {
+		standings[i] = Standing{
+			Score:            score,
+			Position:         i + 1,
+			PreviousPosition: previous[score.Player],
+			Change:           score.Points - previousPoints[score.Player],
+		}
+	}
+
+	return standings
+}
+
+// Event describes a change to a session.
+type Event struct {
+	Type EventType `json:"type"`
+	// Session is the session as of the event.
+	Session *Session `json:"session"`
+	// Race is the most recently completed race in Session, if any.
+	Race *Race `json:"race,omitempty"`
+	// RaceNumber is the 1-indexed number of Race in Session.
+	RaceNumber int        `json:"raceNumber,omitempty"`
+	Standings  []Standing `json:"standings"`
+	// Bracket is the bracket that Session was recorded in as a heat, if any.
+	Bracket *Bracket `json:"bracket,omitempty"`
+}
+
+// Final reports whether e is the last event for its session.
+func (e *Event) Final() bool {
+	return e.Type == EventSessionFinal
+}
+
+// NewEvent returns an Event of the given type for session as it is.
+func NewEvent(eventType EventType, session *Session, opts ...ScoreSessionOpt) *Event {
+	e := &Event{
+		Type:      eventType,
+		Session:   session,
+		Standings: Standings(session, opts...),
+	}
+
+	if session != nil && len(session.Races) > 0 {
+		e.RaceNumber = len(session.Races)
+		e.Race = &session.Races[e.RaceNumber-1]
+	}
+
+	return e
+}
+
+// NewUpdateEvent returns the Event for a session updated through Sink.UpdateSession
+// with the given options, for sinks that handle both in the same way.
+func NewUpdateEvent(session *Session, opts ...UpdateSessionOpt) *Event {
+	o := new(UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	eventType := EventSessionUpdated
+	if o.Final {
+		eventType = EventSessionFinal
+	}
+
+	e := NewEvent(eventType, session, o.ScoreSessionOpts)
+	e.Bracket = o.Bracket
+
+	return e
+}
+
+// EventSink is an optional interface for a Sink to implement to receive
+// Events describing what changed instead of the whole session on every update.
+type EventSink interface {
+	HandleEvent(context.Context, *Event, ...UpdateSessionOpt) error
+}
+
+// SinkEventSink adapts a Sink that does not implement EventSink to one that
+// does by sending it each Event's Session as an update.
+type SinkEventSink struct {
+	Sink
+}
+
+// HandleEvent implements EventSink.
+func (s *SinkEventSink) HandleEvent(ctx context.Context, event *Event, opts ...UpdateSessionOpt) error {
+	return s.UpdateSession(ctx, event.Session, append(opts, &UpdateSessionOpts{Final: event.Final()})...)
+}
+
+// AsEventSink returns sink as an EventSink, adapting it with
+// SinkEventSink if it does not implement EventSink itself.
+func AsEventSink(sink Sink) EventSink {
+	if eventSink, ok := sink.(EventSink); ok {
+		return eventSink
+	}
+
+	return &SinkEventSink{Sink: sink}
+}
+
+// EventEmitter is a Sink that works out what changed in the session since
+// the previous update and sends the resulting Events to the wrapped Sink.
+type EventEmitter struct {
+	Sink Sink
+
+	mu      sync.Mutex
+	started bool
+	date    time.Time
+	races   int
+	// results are the results of the latest race as of the previous update.
+	results []Result
+}
+
+// UpdateSession implements Sink.
+func (e *EventEmitter) UpdateSession(ctx context.Context, session *Session, opts ...UpdateSessionOpt) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o := new(UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	var (
+		events    = []*Event{}
+		eventSink = AsEventSink(e.Sink)
+	)
+
+	if !e.started || !session.Date.Equal(e.date) || len(session.Races) < e.races {
+		e.started = true
+		e.date = session.Date
+		e.races = len(session.Races)
+
+		events = append(events, NewEvent(EventSessionStarted, session, o.ScoreSessionOpts))
+	}
+
+	for ; e.races < len(session.Races); e.races++ {
+		before := *session
+		before.Races = session.Races[:e.races+1]
+
+		events = append(events, NewEvent(EventRaceCompleted, &before, o.ScoreSessionOpts))
+	}
+
+	results := latestResults(session)
+	if len(events) == 0 && !slices.Equal(results, e.results) {
+		events = append(events, NewEvent(EventSessionUpdated, session, o.ScoreSessionOpts))
+	}
+	e.results = results
+
+	if o.Final {
+		event := NewEvent(EventSessionFinal, session, o.ScoreSessionOpts)
+		event.Bracket = o.Bracket
+		events = append(events, event)
+	}
+
+	var errs []error
+
+	for _, event := range events {
+		if err := eventSink.HandleEvent(ctx, event, append(opts, &UpdateSessionOpts{Final: event.Final()})...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// latestResults returns a copy of the results of session's latest race.
+func latestResults(session *Session) []Result {
+	if len(session.Races) == 0 {
+		return nil
+	}
+
+	return slices.Clone(session.Races[len(session.Races)-1].Results)
+}
+
+// Flush implements Flusher.
+func (e *EventEmitter) Flush(ctx context.Context) error {
+	return SinkWrapper{e.Sink}.Flush(ctx)
+}
+
+// Close implements io.Closer.
+func (e *EventEmitter) Close() error {
+	return SinkWrapper{e.Sink}.Close()
+}
@@ -0,0 +1,177 @@
+package rvglutils_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+type eventSink struct {
+	events []*rvglutils.Event
+}
+
+func (s *eventSink) UpdateSession(context.Context, *rvglutils.Session, ...rvglutils.UpdateSessionOpt) error {
+	panic("unexpected call to UpdateSession")
+}
+
+func (s *eventSink) HandleEvent(_ context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+type sink struct {
+	sessions []*rvglutils.Session
+	final    []bool
+}
+
+func (s *sink) UpdateSession(_ context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	s.sessions = append(s.sessions, session)
+	s.final = append(s.final, o.Final)
+	return nil
+}
+
+func TestEventEmitter(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		ctx     = context.TODO()
+		started = *session
+		s       = &eventSink{}
+		emitter = &rvglutils.EventEmitter{Sink: s}
+	)
+	started.Races = session.Races[:2]
+
+	if err := emitter.UpdateSession(ctx, &started); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := emitter.UpdateSession(ctx, session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := emitter.UpdateSession(ctx, session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	expected := []rvglutils.EventType{
+		rvglutils.EventSessionStarted,
+		rvglutils.EventRaceCompleted,
+		rvglutils.EventRaceCompleted,
+		rvglutils.EventSessionFinal,
+	}
+
+	if len(s.events) != len(expected) {
+		t.Fatalf("unexpected number of events: %d", len(s.events))
+	}
+
+	for i, event := range s.events {
+		if event.Type != expected[i] {
+			t.Fatalf("unexpected event %d: %s", i, event.Type)
+		}
+	}
+
+	raceCompleted := s.events[2]
+	if raceCompleted.RaceNumber != 4 || len(raceCompleted.Session.Races) != 4 {
+		t.Fatal("unexpected race number:", raceCompleted.RaceNumber)
+	}
+
+	if raceCompleted.Standings[0].Player != "FRANTJC" || raceCompleted.Standings[0].Change != 11 {
+		t.Fatal("unexpected change for 1st place:", raceCompleted.Standings[0].Change)
+	}
+}
+
+func TestEventEmitterRaceWritten(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		ctx     = context.TODO()
+		written = *session
+		s       = &eventSink{}
+		emitter = &rvglutils.EventEmitter{Sink: s}
+	)
+	// The last race has only been partially written to the session log.
+	written.Races = slices.Clone(session.Races)
+	written.Races[len(written.Races)-1].Results = session.Races[len(session.Races)-1].Results[:1]
+
+	if err := emitter.UpdateSession(ctx, &written); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	for range 2 {
+		if err := emitter.UpdateSession(ctx, session); err != nil {
+			t.Fatalf("update session: %v", err)
+		}
+	}
+
+	expected := []rvglutils.EventType{
+		rvglutils.EventSessionStarted,
+		rvglutils.EventSessionUpdated,
+	}
+
+	if len(s.events) != len(expected) {
+		t.Fatalf("unexpected number of events: %d", len(s.events))
+	}
+
+	for i, event := range s.events {
+		if event.Type != expected[i] {
+			t.Fatalf("unexpected event %d: %s", i, event.Type)
+		}
+	}
+
+	if updated := s.events[1]; len(updated.Race.Results) != len(session.Races[len(session.Races)-1].Results) {
+		t.Fatal("unexpected results in updated race:", updated.Race.Results)
+	}
+}
+
+func TestEventEmitterSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		ctx     = context.TODO()
+		s       = &sink{}
+		emitter = &rvglutils.EventEmitter{Sink: s}
+	)
+
+	if err := emitter.UpdateSession(ctx, session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := emitter.UpdateSession(ctx, session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if len(s.sessions) != 2 {
+		t.Fatal("unexpected number of updates:", len(s.sessions))
+	}
+
+	if s.final[0] || !s.final[1] {
+		t.Fatal("unexpected final updates:", s.final)
+	}
+}
+
+func TestStandings(t *testing.T) {
+	session := testdata.Session(t)
+
+	standings := rvglutils.Standings(session, &rvglutils.ScoreSessionOpts{IncludeAI: true})
+
+	for _, standing := range standings {
+		if standing.Player == "Probe 24" {
+			if standing.Change != 12 {
+				t.Fatal("unexpected change:", standing.Change)
+			}
+
+			if standing.PreviousPosition <= standing.Position {
+				t.Fatalf("expected to move up from %d, got %d", standing.PreviousPosition, standing.Position)
+			}
+		}
+	}
+}
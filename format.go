@@ -0,0 +1,119 @@
+package rvglutils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// ParseFormat parses a Format from its name or a common file extension for it.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	}
+
+	return "", fmt.Errorf(`invalid format %q, expected "json", "csv", "markdown" or "html"`, s)
+}
+
+// Title returns a one-line description of session.
+func Title(session *Session) string {
+	return fmt.Sprintf("%s, %s, hosted by %s on %s", session.Version, session.Mode, session.Host, session.Date.Format(time.RFC3339))
+}
+
+// FormatChange formats a Standing's Change with its sign.
+func FormatChange(change float64) string {
+	if change > 0 {
+		return "+" + strconv.FormatFloat(change, 'g', -1, 64)
+	}
+
+	return strconv.FormatFloat(change, 'g', -1, 64)
+}
+
+var (
+	htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+		"title":  Title,
+		"change": FormatChange,
+	}).Parse(`<h1>{{ title .Session }}</h1>
+<table>
+<thead><tr><th>#</th><th>Player</th><th>Points</th><th>Change</th></tr></thead>
+<tbody>
+{{- range .Standings }}
+<tr{{ if and $.Final (eq .Position 1) }} class="winner"{{ end }}><td>{{ .Position }}</td><td>{{ .Player }}</td><td>{{ .Points }}</td><td>{{ change .Change }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+`))
+)
+
+// EncodeEvent writes event's standings to w in the given format.
+// JSON includes the whole Event.
+func EncodeEvent(w io.Writer, format Format, event *Event) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(event)
+	case FormatCSV:
+		c := csv.NewWriter(w)
+
+		if err := c.Write([]string{"Position", "Player", "Points", "Change"}); err != nil {
+			return err
+		}
+
+		for _, standing := range event.Standings {
+			if err := c.Write([]string{
+				strconv.Itoa(standing.Position),
+				standing.Player,
+				strconv.FormatFloat(standing.Points, 'g', -1, 64),
+				strconv.FormatFloat(standing.Change, 'g', -1, 64),
+			}); err != nil {
+				return err
+			}
+		}
+
+		c.Flush()
+		return c.Error()
+	case FormatMarkdown:
+		if _, err := fmt.Fprintf(w, "# %s\n\n| # | Player | Points | Change |\n| --- | --- | --- | --- |\n", Title(event.Session)); err != nil {
+			return err
+		}
+
+		for _, standing := range event.Standings {
+			player := strings.ReplaceAll(standing.Player, "|", `\|`)
+			if event.Final() && standing.Position == 1 {
+				player = fmt.Sprintf("**WINNER! %s**", player)
+			}
+
+			if _, err := fmt.Fprintf(w, "| %d | %s | %g | %s |\n", standing.Position, player, standing.Points, FormatChange(standing.Change)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case FormatHTML:
+		return htmlTemplate.Execute(w, event)
+	}
+
+	return fmt.Errorf("unsupported format %q", format)
+}
@@ -0,0 +1,64 @@
+package rvglutils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	session := testdata.Session(t)
+
+	event := rvglutils.NewEvent(rvglutils.EventSessionFinal, session)
+
+	for _, format := range []rvglutils.Format{rvglutils.FormatJSON, rvglutils.FormatCSV, rvglutils.FormatMarkdown, rvglutils.FormatHTML} {
+		buf := new(bytes.Buffer)
+
+		if err := rvglutils.EncodeEvent(buf, format, event); err != nil {
+			t.Fatalf("encode %s: %v", format, err)
+		}
+
+		if !strings.Contains(buf.String(), "FRANTJC") {
+			t.Fatalf("player missing from %s: %s", format, buf.String())
+		}
+	}
+}
+
+func TestEncodeEventJSON(t *testing.T) {
+	session := testdata.Session(t)
+
+	buf := new(bytes.Buffer)
+
+	if err := rvglutils.EncodeEvent(buf, rvglutils.FormatJSON, rvglutils.NewEvent(rvglutils.EventRaceCompleted, session)); err != nil {
+		t.Fatalf("encode json: %v", err)
+	}
+
+	event := &rvglutils.Event{}
+	if err := json.Unmarshal(buf.Bytes(), event); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+
+	if event.Type != rvglutils.EventRaceCompleted || event.RaceNumber != 4 || event.Standings[0].Points != 47 {
+		t.Fatal("unexpected event:", buf.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for s, expected := range map[string]rvglutils.Format{
+		".md":  rvglutils.FormatMarkdown,
+		"JSON": rvglutils.FormatJSON,
+		"htm":  rvglutils.FormatHTML,
+	} {
+		if format, err := rvglutils.ParseFormat(s); err != nil || format != expected {
+			t.Fatalf("parse %q: got %q, %v", s, format, err)
+		}
+	}
+
+	if _, err := rvglutils.ParseFormat(".txt"); err == nil {
+		t.Fatal("expected error parsing .txt")
+	}
+}
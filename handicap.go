@@ -0,0 +1,145 @@
+package rvglutils
+
+import (
+	"math"
+	"sort"
+)
+
+type SuggestHandicapOpts struct {
+	Races            int
+	ScoreSessionOpts *ScoreSessionOpts
+}
+
+func (o *SuggestHandicapOpts) Apply(opts *SuggestHandicapOpts) {
+	if o != nil {
+		if opts != nil {
+			if o.Races > 0 {
+				opts.Races = o.Races
+			}
+			if o.ScoreSessionOpts != nil {
+				opts.ScoreSessionOpts = o.ScoreSessionOpts
+			}
+		}
+	}
+}
+
+type SuggestHandicapOpt interface {
+	Apply(*SuggestHandicapOpts)
+}
+
+// Rating is a player's historical performance across sessions.
+type Rating struct {
+	Player string
+	Races  int
+	// Points is the average number of points scored per race.
+	Points float64
+}
+
+// RatePlayers returns the average points per race that each player
+// scored across the given sessions, highest first.
+func RatePlayers(sessions []*Session, opts ...ScoreSessionOpt) []Rating {
+	var (
+		o      = newScoreSessionOpts(opts...)
+		points = map[string]float64{}
+		races  = map[string]int{}
+	)
+
+	for _, session := range sessions {
+		if session == nil {
+			continue
+		}
+
+		exclude := min(max(o.ExcludeRaces, 0), len(session.Races))
+
+		for _, race := range session.Races[exclude:] {
+			for _, result := range race.Results {
+				p, ok := o.scoreResult(&race, &result)
+				if !ok {
+					continue
+				}
+
+				points[result.Player] += p
+				races[result.Player]++
+			}
+		}
+	}
+
+	var (
+		ratings = make([]Rating, len(races))
+		i       = 0
+	)
+	for player, n := range races {
+		ratings[i] = Rating{
+			Player: player,
+			Races:  n,
+			Points: points[player] / float64(n),
+		}
+		i++
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Points == ratings[j].Points {
+			return ratings[i].Player < ratings[j].Player
+		}
+
+		return ratings[i].Points > ratings[j].Points
+	})
+
+	return ratings
+}
+
+func newSuggestHandicapOpts(opts ...SuggestHandicapOpt) *SuggestHandicapOpts {
+	o := &SuggestHandicapOpts{}
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	return o
+}
+
+// SuggestHandicap returns the starting points that each player would need
+// for everyone to be expected to finish a session of Races races level with
+// the best-rated player. Races defaults to the average number of scored races
+// per session. Players that need no handicap are omitted.
+func SuggestHandicap(sessions []*Session, opts ...SuggestHandicapOpt) map[string]int {
+	var (
+		o        = newSuggestHandicapOpts(opts...)
+		ratings  = RatePlayers(sessions, o.ScoreSessionOpts)
+		handicap = map[string]int{}
+	)
+
+	if len(ratings) == 0 {
+		return handicap
+	}
+
+	if o.Races <= 0 {
+		var (
+			o2      = newScoreSessionOpts(o.ScoreSessionOpts)
+			races   = 0
+			counted = 0
+		)
+		for _, session := range sessions {
+			if session == nil {
+				continue
+			}
+
+			races += len(session.Races) - min(max(o2.ExcludeRaces, 0), len(session.Races))
+			counted++
+		}
+
+		if counted > 0 {
+			o.Races = int(math.Round(float64(races) / float64(counted)))
+		}
+	}
+
+	best := ratings[0].Points
+
+	for _, rating := range ratings[1:] {
+		if points := int(math.Round((best - rating.Points) * float64(o.Races))); points > 0 {
+			handicap[rating.Player] = points
+		}
+	}
+
+	return handicap
+}
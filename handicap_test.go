@@ -0,0 +1,74 @@
+package rvglutils_test
+
+import (
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestRatePlayers(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		ratings    = rvglutils.RatePlayers([]*rvglutils.Session{session, session}, &rvglutils.ScoreSessionOpts{IncludeAI: true})
+		lenRatings = len(ratings)
+	)
+
+	if lenRatings == 0 {
+		t.Fatal("empty ratings")
+	}
+
+	for i, rating := range ratings {
+		if i < lenRatings-1 && rating.Points < ratings[i+1].Points {
+			t.Fatal("ratings not sorted by points descending")
+		}
+	}
+
+	if ratings[0].Player != "FRANTJC" {
+		t.Fatal("unexpected player in 1st:", ratings[0].Player)
+	}
+
+	if ratings[0].Races != 8 {
+		t.Fatal("unexpected 1st place races:", ratings[0].Races)
+	}
+
+	if ratings[0].Points != 11.75 {
+		t.Fatal("unexpected 1st place rating:", ratings[0].Points)
+	}
+}
+
+func TestSuggestHandicap(t *testing.T) {
+	session := testdata.Session(t)
+
+	handicap := rvglutils.SuggestHandicap([]*rvglutils.Session{session}, &rvglutils.SuggestHandicapOpts{
+		ScoreSessionOpts: &rvglutils.ScoreSessionOpts{IncludeAI: true},
+	})
+
+	if _, ok := handicap["FRANTJC"]; ok {
+		t.Fatal("unexpected handicap for best-rated player")
+	}
+
+	if handicap["Glacier"] != 4 {
+		t.Fatal("unexpected handicap for Glacier:", handicap["Glacier"])
+	}
+
+	scores := rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{IncludeAI: true, Handicap: handicap})
+
+	if scores[0].Points != scores[1].Points {
+		t.Fatalf("handicap did not equalize top 2: %g != %g", scores[0].Points, scores[1].Points)
+	}
+}
+
+func TestSuggestHandicapRaces(t *testing.T) {
+	session := testdata.Session(t)
+
+	handicap := rvglutils.SuggestHandicap([]*rvglutils.Session{session}, &rvglutils.SuggestHandicapOpts{
+		Races:            8,
+		ScoreSessionOpts: &rvglutils.ScoreSessionOpts{IncludeAI: true},
+	})
+
+	if handicap["Glacier"] != 8 {
+		t.Fatal("unexpected handicap for Glacier:", handicap["Glacier"])
+	}
+}
@@ -0,0 +1,184 @@
+// Package metrics exposes what rvglsm is doing as Prometheus metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	Namespace = "rvglsm"
+)
+
+// Metrics holds rvglsm's metrics in their own Registry.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	racesCompleted prometheus.Gauge
+	points         *prometheus.GaugeVec
+	position       *prometheus.GaugeVec
+	bestLap        *prometheus.GaugeVec
+	sinkUpdates    *prometheus.CounterVec
+	sinkLatency    *prometheus.HistogramVec
+	watcherEvents  *prometheus.CounterVec
+}
+
+// New returns Metrics registered with a new Registry
+// along with the standard Go and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		racesCompleted: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "races_completed",
+			Help:      "Number of races completed in the current session.",
+		}),
+		points: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "player_points",
+			Help:      "Points scored by each player in the current session.",
+		}, []string{"player"}),
+		position: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "player_position",
+			Help:      "Position of each player in the current session's standings.",
+		}, []string{"player"}),
+		bestLap: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "player_best_lap_seconds",
+			Help:      "Best lap of each player in the current session.",
+		}, []string{"player"}),
+		sinkUpdates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "sink_updates_total",
+			Help:      "Updates sent to each sink by whether they succeeded.",
+		}, []string{"sink", "scheme", "result"}),
+		sinkLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "sink_update_duration_seconds",
+			Help:      "How long updates to each sink took.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"sink", "scheme"}),
+		watcherEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "watcher_events_total",
+			Help:      "File system events seen for the session .csv by operation.",
+		}, []string{"op"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.racesCompleted,
+		m.points,
+		m.position,
+		m.bestLap,
+		m.sinkUpdates,
+		m.sinkLatency,
+		m.watcherEvents,
+	)
+
+	return m
+}
+
+// Handler returns an http.Handler that serves the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// ObserveSession sets the session's metrics from session.
+func (m *Metrics) ObserveSession(session *rvglutils.Session, opts ...rvglutils.ScoreSessionOpt) {
+	// Reset so that players who are no longer in the session are dropped.
+	m.points.Reset()
+	m.position.Reset()
+	m.bestLap.Reset()
+
+	o := &rvglutils.ScoreSessionOpts{}
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	// Count only the races that are scored, the same as the standings.
+	m.racesCompleted.Set(float64(len(session.Races) - min(max(o.ExcludeRaces, 0), len(session.Races))))
+
+	for i, score := range rvglutils.ScoreSession(session, o) {
+		m.points.WithLabelValues(score.Player).Set(score.Points)
+		m.position.WithLabelValues(score.Player).Set(float64(i + 1))
+	}
+
+	for player, bestLap := range rvglutils.BestLaps(session, o) {
+		m.bestLap.WithLabelValues(player).Set(bestLap.Seconds())
+	}
+}
+
+// ObserveWatcherEvent counts a file system event for the session .csv.
+func (m *Metrics) ObserveWatcherEvent(op string) {
+	m.watcherEvents.WithLabelValues(op).Inc()
+}
+
+// Sink returns a Sink that observes each session that it is
+// updated with before passing the update on to sink.
+func (m *Metrics) Sink(sink rvglutils.Sink) rvglutils.Sink {
+	return &sessionSink{SinkWrapper: rvglutils.SinkWrapper{Sink: sink}, metrics: m}
+}
+
+// InstrumentSink returns a Sink that counts the updates to sink by whether they
+// succeeded and times them, labeled with the given name and sink's URL scheme.
+func (m *Metrics) InstrumentSink(name, scheme string, sink rvglutils.Sink) rvglutils.Sink {
+	return &instrumentedSink{SinkWrapper: rvglutils.SinkWrapper{Sink: sink}, metrics: m, name: name, scheme: scheme}
+}
+
+type sessionSink struct {
+	rvglutils.SinkWrapper
+	metrics *Metrics
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *sessionSink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	s.metrics.ObserveSession(session, o.ScoreSessionOpts)
+
+	return s.Sink.UpdateSession(ctx, session, opts...)
+}
+
+type instrumentedSink struct {
+	rvglutils.SinkWrapper
+	metrics      *Metrics
+	name, scheme string
+}
+
+func (s *instrumentedSink) observe(start time.Time, err error) error {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	s.metrics.sinkUpdates.WithLabelValues(s.name, s.scheme, result).Inc()
+	s.metrics.sinkLatency.WithLabelValues(s.name, s.scheme).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *instrumentedSink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	start := time.Now()
+	return s.observe(start, s.Sink.UpdateSession(ctx, session, opts...))
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *instrumentedSink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	start := time.Now()
+	return s.observe(start, rvglutils.AsEventSink(s.Sink).HandleEvent(ctx, event, opts...))
+}
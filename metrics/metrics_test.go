@@ -0,0 +1,90 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/metrics"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+type sink struct {
+	err error
+}
+
+func (s *sink) UpdateSession(context.Context, *rvglutils.Session, ...rvglutils.UpdateSessionOpt) error {
+	return s.err
+}
+
+func TestMetrics(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		m         = metrics.New()
+		succeeded = m.InstrumentSink("0", "discord", &sink{})
+		failed    = m.InstrumentSink("1", "slack", &sink{err: errors.New("failed")})
+		s         = m.Sink(rvglutils.MultiSink{succeeded, failed})
+		opts      = &rvglutils.ScoreSessionOpts{IncludeAI: true}
+	)
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{ScoreSessionOpts: opts}); err == nil {
+		t.Error("expected error from failing sink")
+	}
+
+	m.ObserveWatcherEvent("WRITE")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	b, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+
+	var (
+		body   = string(b)
+		scores = rvglutils.ScoreSession(session, opts)
+	)
+
+	for _, expected := range []string{
+		fmt.Sprintf("rvglsm_races_completed %d", len(session.Races)),
+		fmt.Sprintf(`rvglsm_player_points{player=%q} %g`, scores[0].Player, scores[0].Points),
+		fmt.Sprintf(`rvglsm_player_position{player=%q} 1`, scores[0].Player),
+		fmt.Sprintf(`rvglsm_player_best_lap_seconds{player=%q}`, scores[0].Player),
+		`rvglsm_sink_updates_total{result="success",scheme="discord",sink="0"} 1`,
+		`rvglsm_sink_updates_total{result="failure",scheme="slack",sink="1"} 1`,
+		`rvglsm_sink_update_duration_seconds_count{scheme="discord",sink="0"} 1`,
+		`rvglsm_watcher_events_total{op="WRITE"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected metrics to contain %q", expected)
+		}
+	}
+}
+
+func TestMetricsFiltering(t *testing.T) {
+	session := testdata.Session(t)
+
+	m := metrics.New()
+	m.ObserveSession(session, &rvglutils.ScoreSessionOpts{ExcludeRaces: 1})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+
+	if expected := fmt.Sprintf("rvglsm_races_completed %d", len(session.Races)-1); !strings.Contains(body, expected) {
+		t.Errorf("expected metrics to contain %q", expected)
+	}
+
+	// "Glacier" is an AI player, so it has no metrics without IncludeAI.
+	if unexpected := `player="Glacier"`; strings.Contains(body, unexpected) {
+		t.Errorf("expected metrics not to contain %q", unexpected)
+	}
+}
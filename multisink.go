@@ -0,0 +1,118 @@
+package rvglutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SinkError is the error returned by one of a MultiSink's Sinks.
+type SinkError struct {
+	// Index is the index of the Sink within the MultiSink.
+	Index int
+	// URL is the URL that the Sink was opened from, if it is a *URLSink.
+	URL  string
+	Sink Sink
+	Err  error
+}
+
+// Error implements error.
+func (e *SinkError) Error() string {
+	if e.URL != "" {
+		return fmt.Sprintf("sink %d (%s): %v", e.Index, e.URL, e.Err)
+	}
+
+	return fmt.Sprintf("sink %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the Sink's error.
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// MultiSink sends each update to all of its Sinks concurrently, so an update
+// takes as long as the slowest Sink rather than all of them added together,
+// and one failing Sink does not stop the others from being updated. Each
+// update still waits for every Sink before returning. Errors are returned
+// as a joined error of a *SinkError for each Sink that failed.
+type MultiSink []Sink
+
+func (m MultiSink) each(f func(Sink) error) error {
+	var (
+		errs = make([]error, len(m))
+		wg   sync.WaitGroup
+	)
+
+	for i, sink := range m {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := f(sink); err != nil {
+				sinkErr := &SinkError{Index: i, Sink: sink, Err: err}
+				if urlSink, ok := sink.(*URLSink); ok {
+					sinkErr.URL = urlSink.URL
+				}
+
+				errs[i] = sinkErr
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// UpdateSession implements Sink.
+func (m MultiSink) UpdateSession(ctx context.Context, session *Session, opts ...UpdateSessionOpt) error {
+	return m.each(func(sink Sink) error {
+		return sink.UpdateSession(ctx, session, opts...)
+	})
+}
+
+// HandleEvent implements EventSink.
+func (m MultiSink) HandleEvent(ctx context.Context, event *Event, opts ...UpdateSessionOpt) error {
+	return m.each(func(sink Sink) error {
+		return AsEventSink(sink).HandleEvent(ctx, event, opts...)
+	})
+}
+
+// Flush implements Flusher.
+func (m MultiSink) Flush(ctx context.Context) error {
+	return m.each(func(sink Sink) error {
+		return SinkWrapper{sink}.Flush(ctx)
+	})
+}
+
+// Close implements io.Closer.
+func (m MultiSink) Close() error {
+	return m.each(func(sink Sink) error {
+		return SinkWrapper{sink}.Close()
+	})
+}
+
+// URLSink is a Sink along with the URL that it was opened from,
+// for a MultiSink to identify it by in its errors. As such, URL
+// should not include any secrets that were in the original.
+type URLSink struct {
+	Sink
+	URL string
+}
+
+// HandleEvent implements EventSink.
+func (s *URLSink) HandleEvent(ctx context.Context, event *Event, opts ...UpdateSessionOpt) error {
+	return AsEventSink(s.Sink).HandleEvent(ctx, event, opts...)
+}
+
+// Flush implements Flusher.
+func (s *URLSink) Flush(ctx context.Context) error {
+	return SinkWrapper{s.Sink}.Flush(ctx)
+}
+
+// Close implements io.Closer.
+func (s *URLSink) Close() error {
+	return SinkWrapper{s.Sink}.Close()
+}
@@ -0,0 +1,45 @@
+package rvglutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+type failingSink struct{}
+
+func (failingSink) UpdateSession(context.Context, *rvglutils.Session, ...rvglutils.UpdateSessionOpt) error {
+	return errors.New("failed")
+}
+
+func TestMultiSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		s  = &sink{}
+		es = &eventSink{}
+		m  = rvglutils.MultiSink{&rvglutils.URLSink{Sink: failingSink{}, URL: "failing://"}, s, &rvglutils.URLSink{Sink: es, URL: "event://"}}
+	)
+
+	err := (&rvglutils.EventEmitter{Sink: m}).UpdateSession(context.TODO(), session, &rvglutils.UpdateSessionOpts{Final: true})
+
+	sinkErr := &rvglutils.SinkError{}
+	if !errors.As(err, &sinkErr) {
+		t.Fatal("expected sink error, got:", err)
+	}
+
+	if sinkErr.Index != 0 || sinkErr.URL != "failing://" {
+		t.Fatal("unexpected failing sink:", sinkErr.Index, sinkErr.URL)
+	}
+
+	if len(s.sessions) != 2 || !s.final[1] {
+		t.Fatal("unexpected updates:", s.final)
+	}
+
+	if len(es.events) != 2 || es.events[1].Type != rvglutils.EventSessionFinal {
+		t.Fatal("unexpected number of events:", len(es.events))
+	}
+}
@@ -0,0 +1,63 @@
+package rvglutils
+
+import "sort"
+
+type SessionRole string
+
+const (
+	RoleRace       SessionRole = "race"
+	RoleQualifying SessionRole = "qualifying"
+)
+
+// SessionRecord is what is known about a session beyond its .csv.
+type SessionRecord struct {
+	Role SessionRole `json:"role"`
+	// Seeding is the order in which players qualified in a qualifying session.
+	Seeding []string `json:"seeding,omitempty"`
+	// Qualifying is the .csv of the qualifying session that seeds a race session.
+	Qualifying string `json:"qualifying,omitempty"`
+}
+
+// SessionIndex links sessions together, keyed by the path to each session's .csv.
+type SessionIndex map[string]*SessionRecord
+
+// Seeding returns the seeding for the session with the given .csv, either
+// because it is a qualifying session or because it was seeded by one.
+func (i SessionIndex) Seeding(sessionCSV string) []string {
+	record, ok := i[sessionCSV]
+	if !ok {
+		return nil
+	}
+
+	if record.Role == RoleQualifying {
+		return record.Seeding
+	}
+
+	if qualifying, ok := i[record.Qualifying]; ok {
+		return qualifying.Seeding
+	}
+
+	return nil
+}
+
+// Qualify ranks the players in a qualifying session by the best lap that
+// each of them set across its races, as reported by BestLaps. Players
+// without a lap that counts are left unranked.
+func Qualify(session *Session, opts ...ScoreSessionOpt) []string {
+	bestLaps := BestLaps(session, opts...)
+
+	seeding := make([]string, 0, len(bestLaps))
+	for player := range bestLaps {
+		seeding = append(seeding, player)
+	}
+
+	sort.Slice(seeding, func(i, j int) bool {
+		if bi, bj := bestLaps[seeding[i]], bestLaps[seeding[j]]; bi != bj {
+			return bi < bj
+		}
+
+		return seeding[i] < seeding[j]
+	})
+
+	return seeding
+}
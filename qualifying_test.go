@@ -0,0 +1,64 @@
+package rvglutils_test
+
+import (
+	"slices"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestQualify(t *testing.T) {
+	session := testdata.Session(t)
+
+	seeding := rvglutils.Qualify(session, &rvglutils.ScoreSessionOpts{IncludeAI: true})
+
+	if len(seeding) != 12 {
+		t.Fatal("unexpected number of seeds:", len(seeding))
+	}
+
+	if !slices.Equal(seeding[:3], []string{"FRANTJC", "Probe 24", "Glacier"}) {
+		t.Fatal("unexpected top 3 seeds:", seeding[:3])
+	}
+}
+
+func TestScoreSessionSeeding(t *testing.T) {
+	session := &rvglutils.Session{
+		Races: []rvglutils.Race{
+			{
+				Results: []rvglutils.Result{
+					{Position: 1, Player: "FRANTJC", Car: "Candy Cane"},
+					{Position: 2, Player: "GLACIER", Car: "Candy Cane"},
+				},
+			},
+		},
+	}
+
+	scores := rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{
+		Handicap: map[string]int{"GLACIER": 1},
+		Seeding:  []string{"GLACIER", "FRANTJC"},
+	})
+
+	if scores[0].Points != scores[1].Points {
+		t.Fatalf("expected tie: %g != %g", scores[0].Points, scores[1].Points)
+	}
+
+	if scores[0].Player != "GLACIER" {
+		t.Fatal("tie not broken by seeding:", scores[0].Player)
+	}
+}
+
+func TestSessionIndexSeeding(t *testing.T) {
+	index := rvglutils.SessionIndex{
+		"qualifying.csv": {Role: rvglutils.RoleQualifying, Seeding: []string{"GLACIER", "FRANTJC"}},
+		"race.csv":       {Role: rvglutils.RoleRace, Qualifying: "qualifying.csv"},
+	}
+
+	if seeding := index.Seeding("race.csv"); !slices.Equal(seeding, []string{"GLACIER", "FRANTJC"}) {
+		t.Fatal("unexpected seeding:", seeding)
+	}
+
+	if seeding := index.Seeding("other.csv"); seeding != nil {
+		t.Fatal("unexpected seeding:", seeding)
+	}
+}
@@ -3,21 +3,28 @@ package rvglutils
 import (
 	"sort"
 	"strings"
+	"time"
 )
 
 type ScoreSessionOpts struct {
-	IncludeAI          bool
-	Interval           int
-	ExtraPointsPerRace int
-	ExcludeRaces       int
-	Handicap           map[string]int
-	Multipliers        map[string]float64
+	IncludeAI          bool               `json:"includeAI,omitempty"`
+	Interval           int                `json:"interval,omitempty"`
+	ExtraPointsPerRace int                `json:"extraPointsPerRace,omitempty"`
+	ExcludeRaces       int                `json:"excludeRaces,omitempty"`
+	Handicap           map[string]int     `json:"handicap,omitempty"`
+	Multipliers        map[string]float64 `json:"multipliers,omitempty"`
+	// Seeding breaks ties between players with the same number of points
+	// in favor of whichever comes first.
+	Seeding []string `json:"seeding,omitempty"`
 }
 
 func (o *ScoreSessionOpts) Apply(opts *ScoreSessionOpts) {
 	if o != nil {
 		if opts != nil {
 			opts.IncludeAI = o.IncludeAI
+			if o.ExtraPointsPerRace > 0 {
+				opts.ExtraPointsPerRace = o.ExtraPointsPerRace
+			}
 			if o.ExcludeRaces > 0 {
 				opts.ExcludeRaces = o.ExcludeRaces
 			}
@@ -30,6 +37,9 @@ func (o *ScoreSessionOpts) Apply(opts *ScoreSessionOpts) {
 			if o.Multipliers != nil {
 				opts.Multipliers = o.Multipliers
 			}
+			if o.Seeding != nil {
+				opts.Seeding = o.Seeding
+			}
 		}
 	}
 }
@@ -39,8 +49,8 @@ type ScoreSessionOpt interface {
 }
 
 type Score struct {
-	Player string
-	Points float64
+	Player string  `json:"player"`
+	Points float64 `json:"points"`
 }
 
 func newScoreSessionOpts(opts ...ScoreSessionOpt) *ScoreSessionOpts {
@@ -74,24 +84,12 @@ func ScoreSession(session *Session, opts ...ScoreSessionOpt) []Score {
 	}
 
 	for _, race := range session.Races[o.ExcludeRaces:] {
-		players := len(race.Results)
-
 		for _, result := range race.Results {
-			if !o.IncludeAI && (result.Car == result.Player || strings.ToUpper(result.Player) != result.Player) {
+			points, ok := o.scoreResult(&race, &result)
+			if !ok {
 				continue
 			}
 
-			points := float64(1 + o.ExtraPointsPerRace + players - result.Position)
-			if points < 0 {
-				points = 0
-			}
-
-			if o.Multipliers != nil {
-				if multiplier, ok := o.Multipliers[result.Car]; ok {
-					points *= multiplier
-				}
-			}
-
 			tmp[result.Player] += points
 
 			if tmp[result.Player] >= float64(o.Interval) && o.Interval > 0 {
@@ -112,9 +110,79 @@ func ScoreSession(session *Session, opts ...ScoreSessionOpt) []Score {
 		i++
 	}
 
+	seeds := make(map[string]int, len(o.Seeding))
+	for i, player := range o.Seeding {
+		seeds[player] = i + 1
+	}
+
 	sort.Slice(score, func(i, j int) bool {
+		if score[i].Points == score[j].Points && len(seeds) > 0 {
+			si, sj := seeds[score[i].Player], seeds[score[j].Player]
+			switch {
+			case si == 0:
+				return false
+			case sj == 0:
+				return true
+			}
+
+			return si < sj
+		}
+
 		return score[i].Points > score[j].Points
 	})
 
 	return score
 }
+
+// BestLaps returns each player's best lap in the races of session that
+// are scored with the given options: those after the ones excluded by
+// ExcludeRaces, leaving out AI players unless IncludeAI is set. Results
+// from cheating players and results without a lap are ignored.
+func BestLaps(session *Session, opts ...ScoreSessionOpt) map[string]time.Duration {
+	bestLaps := map[string]time.Duration{}
+
+	if session == nil {
+		return bestLaps
+	}
+
+	o := newScoreSessionOpts(opts...)
+
+	for _, race := range session.Races[min(max(o.ExcludeRaces, 0), len(session.Races)):] {
+		for _, result := range race.Results {
+			if result.BestLap <= 0 || result.Cheating || (!o.IncludeAI && isAI(&result)) {
+				continue
+			}
+
+			if bestLap, ok := bestLaps[result.Player]; !ok || result.BestLap < bestLap {
+				bestLaps[result.Player] = result.BestLap
+			}
+		}
+	}
+
+	return bestLaps
+}
+
+func isAI(result *Result) bool {
+	return result.Car == result.Player || strings.ToUpper(result.Player) != result.Player
+}
+
+// scoreResult returns the points that the given result in the given race
+// is worth, or false if the result should not be scored at all.
+func (o *ScoreSessionOpts) scoreResult(race *Race, result *Result) (float64, bool) {
+	if !o.IncludeAI && isAI(result) {
+		return 0, false
+	}
+
+	points := float64(1 + o.ExtraPointsPerRace + len(race.Results) - result.Position)
+	if points < 0 {
+		points = 0
+	}
+
+	if o.Multipliers != nil {
+		if multiplier, ok := o.Multipliers[result.Car]; ok {
+			points *= multiplier
+		}
+	}
+
+	return points, true
+}
@@ -1,7 +1,6 @@
 package rvglutils_test
 
 import (
-	"bytes"
 	"testing"
 
 	rvglutils "github.com/frantjc/rvgl-utils"
@@ -9,10 +8,7 @@ import (
 )
 
 func TestScoreSession(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session)
@@ -31,10 +27,7 @@ func TestScoreSession(t *testing.T) {
 }
 
 func TestScoreSessionIncludeAI(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{IncludeAI: true})
@@ -51,10 +44,7 @@ func TestScoreSessionIncludeAI(t *testing.T) {
 }
 
 func TestScoreSessionExclude(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{ExcludeRaces: 1})
@@ -75,10 +65,7 @@ func TestScoreSessionExclude(t *testing.T) {
 }
 
 func TestScoreSessionExcludeOutOfBounds(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	scores := rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{ExcludeRaces: 9})
 
@@ -90,10 +77,7 @@ func TestScoreSessionExcludeOutOfBounds(t *testing.T) {
 }
 
 func TestScoreSessionHandicap(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{Handicap: map[string]int{"FRANTJC": 1}})
@@ -114,10 +98,7 @@ func TestScoreSessionHandicap(t *testing.T) {
 }
 
 func TestScoreSessionIntervalEqualToRacers(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{Interval: 12})
@@ -138,10 +119,7 @@ func TestScoreSessionIntervalEqualToRacers(t *testing.T) {
 }
 
 func TestScoreSessionIntervalOffset(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{Interval: 24})
@@ -162,10 +140,7 @@ func TestScoreSessionIntervalOffset(t *testing.T) {
 }
 
 func TestScoreSessionIgnoreAI(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores    = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{Interval: 24})
@@ -186,10 +161,7 @@ func TestScoreSessionIgnoreAI(t *testing.T) {
 }
 
 func TestScoreSessionMultipliers(t *testing.T) {
-	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(testdata.SessionCSV))
-	if err != nil {
-		t.Fatalf("decode testdata/session.csv: %v", err)
-	}
+	session := testdata.Session(t)
 
 	var (
 		scores = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{Multipliers: map[string]float64{
@@ -210,3 +182,45 @@ func TestScoreSessionMultipliers(t *testing.T) {
 		t.Fatal("unexpected last place score:", scores[0].Points)
 	}
 }
+
+func TestScoreSessionExtraPointsPerRace(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		scores = rvglutils.ScoreSession(session)
+		extra  = rvglutils.ScoreSession(session, &rvglutils.ScoreSessionOpts{ExtraPointsPerRace: 2})
+	)
+
+	if len(scores) == 0 || len(extra) != len(scores) {
+		t.Fatal("unexpected number of scores:", len(extra))
+	}
+
+	if extra[0].Player != scores[0].Player {
+		t.Fatal("unexpected player in 1st:", extra[0].Player)
+	}
+
+	if expected := scores[0].Points + float64(2*len(session.Races)); extra[0].Points != expected {
+		t.Fatalf("expected %g points, got %g", expected, extra[0].Points)
+	}
+}
+
+func TestBestLaps(t *testing.T) {
+	session := testdata.Session(t)
+
+	bestLaps := rvglutils.BestLaps(session)
+	if _, ok := bestLaps["Glacier"]; ok {
+		t.Fatal("unexpected best lap for AI player")
+	}
+
+	if bestLaps["FRANTJC"] <= 0 {
+		t.Fatal("expected best lap for FRANTJC")
+	}
+
+	if _, ok := rvglutils.BestLaps(session, &rvglutils.ScoreSessionOpts{IncludeAI: true})["Glacier"]; !ok {
+		t.Fatal("expected best lap for AI player with IncludeAI")
+	}
+
+	if excluded := rvglutils.BestLaps(session, &rvglutils.ScoreSessionOpts{ExcludeRaces: len(session.Races)}); len(excluded) != 0 {
+		t.Fatal("unexpected best laps with every race excluded:", excluded)
+	}
+}
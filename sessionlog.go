@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -136,29 +137,80 @@ func ResolveSessionCSV(opts ...ResolveSessionCSVOpt) (string, error) {
 	return "", fmt.Errorf("resolve session .csv")
 }
 
+// ResolveSessionCSVs returns every session .csv found on PathList,
+// ordered from oldest to newest.
+func ResolveSessionCSVs(opts ...ResolveSessionCSVOpt) ([]string, error) {
+	var (
+		o     = newResolveSessionCSVOpts(opts...)
+		dirs  = strings.Split(o.PathList, string(os.PathListSeparator))
+		names = []string{}
+		times = map[string]time.Time{}
+	)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			name := entry.Name()
+
+			if !strings.HasPrefix(name, "session_") || !strings.HasSuffix(name, ".csv") {
+				continue
+			}
+
+			value := strings.TrimSuffix(strings.TrimPrefix(name, "session_"), ".csv")
+
+			_time, err := time.Parse("2006-01-02_15-04-05", value)
+			if err != nil {
+				return nil, err
+			}
+
+			name = filepath.Join(dir, name)
+			names = append(names, name)
+			times[name] = _time
+		}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return times[names[i]].Before(times[names[j]])
+	})
+
+	return names, nil
+}
+
 type Session struct {
-	Version string
-	Date    time.Time
-	Host    string
-	Mode    string
-	Laps    int
-	AI      bool
-	Races   []Race
+	Version string    `json:"version"`
+	Date    time.Time `json:"date"`
+	Host    string    `json:"host"`
+	Mode    string    `json:"mode"`
+	Laps    int       `json:"laps"`
+	AI      bool      `json:"ai"`
+	Races   []Race    `json:"races"`
 }
 
 type Race struct {
-	Track   string
-	Results []Result
+	Track   string   `json:"track"`
+	Results []Result `json:"results"`
 }
 
 type Result struct {
-	Position int
-	Player   string
-	Car      string
-	Time     time.Duration
-	BestLap  time.Duration
-	Finished bool
-	Cheating bool
+	Position int           `json:"position"`
+	Player   string        `json:"player"`
+	Car      string        `json:"car"`
+	Time     time.Duration `json:"time"`
+	BestLap  time.Duration `json:"bestLap"`
+	Finished bool          `json:"finished"`
+	Cheating bool          `json:"cheating"`
 }
 
 func DecodeSessionCSV(r io.Reader) (*Session, error) {
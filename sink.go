@@ -2,22 +2,47 @@ package rvglutils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"text/template"
 )
 
 type UpdateSessionOpts struct {
 	Final            bool
 	ScoreSessionOpts *ScoreSessionOpts
+	// Template is used by text-based sinks that were not
+	// opened with a template of their own to render updates.
+	Template *template.Template
+	// SessionCSV is the path to the session .csv that the update is from,
+	// for sinks to key State by.
+	SessionCSV string
+	// State is where sinks can keep state across restarts, if set.
+	State StateStore
+	// Bracket is the bracket that the session was recorded in as a heat, if any.
+	Bracket *Bracket
 }
 
 func (o *UpdateSessionOpts) Apply(opts *UpdateSessionOpts) {
 	if o != nil {
 		if opts != nil {
-			o.Final = opts.Final
+			opts.Final = o.Final
 			if o.ScoreSessionOpts != nil {
 				opts.ScoreSessionOpts = o.ScoreSessionOpts
 			}
+			if o.Template != nil {
+				opts.Template = o.Template
+			}
+			if o.SessionCSV != "" {
+				opts.SessionCSV = o.SessionCSV
+			}
+			if o.State != nil {
+				opts.State = o.State
+			}
+			if o.Bracket != nil {
+				opts.Bracket = o.Bracket
+			}
 		}
 	}
 }
@@ -30,6 +55,56 @@ type Sink interface {
 	UpdateSession(context.Context, *Session, ...UpdateSessionOpt) error
 }
 
+// Flusher is an optional interface for a Sink to implement
+// to write out any updates that it has buffered.
+type Flusher interface {
+	Flush(context.Context) error
+}
+
+// SinkWrapper is a Sink that forwards Flush and Close to the Sink that it
+// wraps if that implements them. Sinks that wrap another can embed it.
+type SinkWrapper struct {
+	Sink
+}
+
+// Flush implements Flusher.
+func (w SinkWrapper) Flush(ctx context.Context) error {
+	if flusher, ok := w.Sink.(Flusher); ok {
+		return flusher.Flush(ctx)
+	}
+
+	return nil
+}
+
+// Close implements io.Closer.
+func (w SinkWrapper) Close() error {
+	if closer, ok := w.Sink.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// CloseSink flushes sink if it implements Flusher
+// and then closes it if it implements io.Closer.
+func CloseSink(ctx context.Context, sink Sink) error {
+	var errs []error
+
+	if flusher, ok := sink.(Flusher); ok {
+		if err := flusher.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush sink: %w", err))
+		}
+	}
+
+	if closer, ok := sink.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close sink: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 type SinkOpener interface {
 	Open(context.Context, *url.URL) (Sink, error)
 }
@@ -48,6 +123,12 @@ func RegisterSink(o SinkOpener, scheme string, schemes ...string) {
 	}
 }
 
+// LookupSink returns the SinkOpener registered for scheme, if any.
+func LookupSink(scheme string) (SinkOpener, bool) {
+	opener, ok := sinkURLMux[scheme]
+	return opener, ok
+}
+
 func OpenSink(ctx context.Context, s string) (Sink, error) {
 	u, err := url.Parse(s)
 	if err != nil {
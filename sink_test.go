@@ -0,0 +1,65 @@
+package rvglutils_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+type closingSink struct {
+	sink
+	calls []string
+}
+
+func (s *closingSink) Flush(context.Context) error {
+	s.calls = append(s.calls, "flush")
+	return nil
+}
+
+func (s *closingSink) Close() error {
+	s.calls = append(s.calls, "close")
+	return errors.New("closed")
+}
+
+func TestCloseSink(t *testing.T) {
+	var (
+		s       = &closingSink{}
+		emitter = &rvglutils.EventEmitter{Sink: s}
+	)
+
+	if err := rvglutils.CloseSink(context.TODO(), emitter); err == nil {
+		t.Fatal("expected close error")
+	}
+
+	if len(s.calls) != 2 || s.calls[0] != "flush" || s.calls[1] != "close" {
+		t.Fatal("unexpected calls:", s.calls)
+	}
+}
+
+func TestCloseSinkNoop(t *testing.T) {
+	if err := rvglutils.CloseSink(context.TODO(), &sink{}); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+}
+
+func TestSinkWrapper(t *testing.T) {
+	s := &closingSink{}
+
+	if err := (rvglutils.SinkWrapper{Sink: s}).Flush(t.Context()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if err := (rvglutils.SinkWrapper{Sink: s}).Close(); err == nil {
+		t.Fatal("expected close error")
+	}
+
+	if len(s.calls) != 2 || s.calls[0] != "flush" || s.calls[1] != "close" {
+		t.Fatal("unexpected calls:", s.calls)
+	}
+
+	if err := rvglutils.CloseSink(t.Context(), rvglutils.SinkWrapper{Sink: &sink{}}); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+}
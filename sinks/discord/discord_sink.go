@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	rvglutils "github.com/frantjc/rvgl-utils"
@@ -21,52 +25,131 @@ const (
 	Scheme = "discord"
 )
 
+const (
+	DefaultAPIURL     = "https://discordapp.com/api"
+	DefaultMaxRetries = 5
+	DefaultBackoff    = time.Second
+)
+
 type Sink struct {
 	WebhookID  string
 	Token      string
 	MessageID  string
 	HTTPClient *http.Client
+	Template   *template.Template
+	// APIURL is the base URL of Discord's API, DefaultAPIURL if empty.
+	APIURL string
+	// MaxRetries is the number of times to retry a failed request,
+	// DefaultMaxRetries if not positive.
+	MaxRetries int
+	// Backoff is how long to wait before the first retry of a request
+	// that failed for reasons other than a rate limit, DefaultBackoff
+	// if not positive. It doubles with each retry.
+	Backoff time.Duration
+
+	resetAt time.Time
 }
 
 // UpdateSession implements rvglutils.Sink.
 func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
-	var (
-		o       = new(rvglutils.UpdateSessionOpts)
-		content = strings.Builder{}
-	)
+	o := new(rvglutils.UpdateSessionOpts)
 
 	for _, opt := range opts {
 		opt.Apply(o)
 	}
 
-	if _, err := content.WriteString(fmt.Sprintf("%s, %s, hosted by %s on %s\n", session.Version, session.Mode, session.Host, session.Date.Format(time.RFC3339))); err != nil {
-		return err
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
 	}
 
-	scores := rvglutils.ScoreSession(session, o.ScoreSessionOpts)
+	// Standings are rendered as embeds unless a template was given,
+	// in which case the template's output is sent as plain content.
+	message := map[string]any{}
 
-	for i, score := range scores {
-		format := "%s: %g\n"
+	if tmpl != nil {
+		content := strings.Builder{}
 
-		if o.Final && i == 0 {
-			format = "**WINNER! %s**: %g\n"
+		if err := tmpl.Execute(&content, rvglutils.NewUpdateEvent(session, opts...)); err != nil {
+			return err
 		}
 
-		if _, err := content.WriteString(fmt.Sprintf(format, score.Player, score.Points)); err != nil {
-			return err
+		message["content"] = content.String()
+	} else {
+		message["content"] = ""
+		message["embeds"] = Embeds(rvglutils.NewUpdateEvent(session, opts...))
+	}
+
+	// Pick up editing the message from a previous run against the same session.
+	var (
+		stateKey = ""
+		restored = false
+	)
+	if o.State != nil && o.SessionCSV != "" {
+		stateKey = fmt.Sprintf("%s:%s:%s", Scheme, s.WebhookID, o.SessionCSV)
+
+		if s.MessageID == "" {
+			messageID, ok, err := o.State.Load(stateKey)
+			if err != nil {
+				return fmt.Errorf("load message ID: %w", err)
+			}
+
+			s.MessageID, restored = messageID, ok
 		}
 	}
 
-	u, err := url.Parse(fmt.Sprintf("https://discordapp.com/api/webhooks/%s/%s", s.WebhookID, s.Token))
+	body, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	body, err := json.Marshal(map[string]string{"content": content.String()})
+	res, err := s.send(ctx, body)
+	if apiErr := (&APIError{}); restored && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// The message was deleted since, so post a new one.
+		s.MessageID = ""
+		res, err = s.send(ctx, body)
+	}
 	if err != nil {
 		return err
 	}
 
+	if s.MessageID == "" {
+		data := struct {
+			ID string `json:"id"`
+		}{}
+
+		if err := json.Unmarshal(res, &data); err != nil {
+			return err
+		} else if data.ID == "" {
+			return fmt.Errorf("missing message ID in successful response")
+		}
+
+		s.MessageID = data.ID
+
+		if stateKey != "" {
+			if err := o.State.Store(stateKey, s.MessageID); err != nil {
+				return fmt.Errorf("store message ID: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// send creates the webhook message with the given body,
+// or edits it if the Sink already has its MessageID.
+func (s *Sink) send(ctx context.Context, body []byte) ([]byte, error) {
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	u = u.JoinPath("webhooks", s.WebhookID, s.Token)
+
 	method := http.MethodPost
 
 	if s.MessageID != "" {
@@ -78,48 +161,153 @@ func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, op
 		u.RawQuery = q.Encode()
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
-	if err != nil {
-		return err
+	return s.do(ctx, method, u, body)
+}
+
+// APIError is an unsuccessful response from Discord.
+type APIError struct {
+	Method     string `json:"-"`
+	Status     string `json:"-"`
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("%s webhook message: %d %s", e.Method, e.Code, e.Message)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
+	return fmt.Sprintf("%s webhook message: %s: %s", e.Method, e.Status, e.Message)
+}
+
+// do sends a request to Discord and returns the body of its successful
+// response. Requests that are rate limited are retried once the limit
+// resets, and requests that fail due to the network or a server error
+// are retried with exponential backoff, up to MaxRetries times.
+func (s *Sink) do(ctx context.Context, method string, u *url.URL, body []byte) ([]byte, error) {
 	if s.HTTPClient == nil {
 		s.HTTPClient = http.DefaultClient
 	}
 
-	res, err := s.HTTPClient.Do(req)
-	if err != nil {
-		return err
+	var (
+		maxRetries = s.MaxRetries
+		backoff    = s.Backoff
+	)
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
 	}
-	defer res.Body.Close() //nolint:errcheck
 
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		data := struct {
-			Message string `json:"message"`
-			Code    int    `json:"code"`
-		}{}
+	for attempt := 0; ; attempt++ {
+		// Wait out a rate limit that a previous response said was exhausted.
+		if err := sleep(ctx, time.Until(s.resetAt)); err != nil {
+			return nil, err
+		}
 
-		if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
-			return err
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
 		}
+		req.Header.Set("Content-Type", "application/json")
 
-		return fmt.Errorf("%s %s: %d %s", method, u, data.Code, data.Message)
-	} else if s.MessageID == "" {
-		data := struct {
-			ID string `json:"id"`
-		}{}
+		var (
+			wait time.Duration
+			res  *http.Response
+		)
+		res, err = s.HTTPClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 
-		if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
-			return err
-		} else if data.ID == "" {
-			return fmt.Errorf("missing message ID in successful response")
+			wait = backoff << attempt
+		} else {
+			b, readErr := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+
+			if res.Header.Get("X-RateLimit-Remaining") == "0" {
+				if resetAfter, ok := parseSeconds(res.Header.Get("X-RateLimit-Reset-After")); ok {
+					s.resetAt = time.Now().Add(resetAfter)
+				}
+			}
+
+			switch {
+			case res.StatusCode == http.StatusTooManyRequests:
+				err = fmt.Errorf("%s webhook message: rate limited", method)
+				wait = retryAfter(res, b, backoff)
+			case res.StatusCode >= 500:
+				err = fmt.Errorf("%s webhook message: %s", method, res.Status)
+				wait = backoff << attempt
+			case res.StatusCode < 200 || res.StatusCode >= 300:
+				apiErr := &APIError{Method: method, Status: res.Status, StatusCode: res.StatusCode}
+
+				if json.Unmarshal(b, apiErr) != nil || apiErr.Message == "" {
+					apiErr.Message = strings.TrimSpace(string(b))
+				}
+
+				return nil, apiErr
+			default:
+				return b, readErr
+			}
 		}
 
-		s.MessageID = data.ID
+		if attempt >= maxRetries {
+			return nil, err
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	return nil
+// retryAfter returns how long to wait before retrying a rate limited
+// request, from its response body if possible, else from its headers,
+// falling back to the given duration if neither of them says.
+func retryAfter(res *http.Response, body []byte, fallback time.Duration) time.Duration {
+	data := struct {
+		RetryAfter float64 `json:"retry_after"`
+	}{}
+
+	if json.Unmarshal(body, &data) == nil && data.RetryAfter > 0 {
+		return time.Duration(data.RetryAfter * float64(time.Second))
+	}
+
+	for _, header := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if d, ok := parseSeconds(res.Header.Get(header)); ok {
+			return d
+		}
+	}
+
+	return fallback
+}
+
+func parseSeconds(s string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 type sinkOpener struct{}
@@ -138,5 +326,12 @@ func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, erro
 		}
 	)
 
+	if text := u.Query().Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
 	return s, nil
 }
@@ -0,0 +1,171 @@
+package discord_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/discord"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestSinkRetries(t *testing.T) {
+	var (
+		requests = 0
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			switch requests {
+			case 1:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"message":"You are being rate limited.","retry_after":0.01,"global":false}`))
+			case 2:
+				w.WriteHeader(http.StatusBadGateway)
+			default:
+				if r.URL.Path != "/webhooks/id/token" || r.URL.Query().Get("wait") != "true" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"1234"}`))
+			}
+		}))
+		s = &discord.Sink{
+			WebhookID: "id",
+			Token:     "token",
+			APIURL:    srv.URL,
+			Backoff:   time.Millisecond,
+		}
+	)
+	defer srv.Close()
+
+	if err := s.UpdateSession(t.Context(), testdata.Session(t)); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	if s.MessageID != "1234" {
+		t.Errorf("expected message ID %q, got %q", "1234", s.MessageID)
+	}
+}
+
+func TestSinkRetriesWithoutRetryAfter(t *testing.T) {
+	var (
+		requests = 0
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if requests++; requests == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"id":"1234"}`))
+		}))
+		s = &discord.Sink{
+			WebhookID: "id",
+			Token:     "token",
+			APIURL:    srv.URL,
+			Backoff:   time.Millisecond,
+		}
+		// DefaultBackoff would not fit in the deadline.
+		ctx, cancel = context.WithTimeout(t.Context(), discord.DefaultBackoff/2)
+	)
+	defer srv.Close()
+	defer cancel()
+
+	if err := s.UpdateSession(ctx, testdata.Session(t)); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("bad request"))
+	}))
+	defer srv.Close()
+
+	s := &discord.Sink{WebhookID: "id", Token: "token", APIURL: srv.URL}
+
+	if err := s.UpdateSession(t.Context(), testdata.Session(t)); err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("expected error containing response body, got %v", err)
+	}
+}
+
+func TestSinkContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	var (
+		s           = &discord.Sink{WebhookID: "id", Token: "token", APIURL: srv.URL}
+		ctx, cancel = context.WithTimeout(t.Context(), 50*time.Millisecond)
+	)
+	defer cancel()
+
+	if err := s.UpdateSession(ctx, testdata.Session(t)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestSinkState(t *testing.T) {
+	var (
+		requests = []string{}
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+
+			if r.URL.Path == "/webhooks/id/token/messages/deleted" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Unknown Message","code":10008}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"id":"1234"}`))
+		}))
+		state = &rvglutils.FileStateStore{Path: filepath.Join(t.TempDir(), "state.json")}
+		opts  = &rvglutils.UpdateSessionOpts{SessionCSV: "/session.csv", State: state}
+	)
+	defer srv.Close()
+
+	for range 2 {
+		s := &discord.Sink{WebhookID: "id", Token: "token", APIURL: srv.URL}
+
+		if err := s.UpdateSession(t.Context(), testdata.Session(t), opts); err != nil {
+			t.Fatalf("update session: %v", err)
+		}
+	}
+
+	if err := state.Store("discord:id:/session.csv", "deleted"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	if err := (&discord.Sink{WebhookID: "id", Token: "token", APIURL: srv.URL}).UpdateSession(t.Context(), testdata.Session(t), opts); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	expected := []string{
+		"POST /webhooks/id/token",
+		"PATCH /webhooks/id/token/messages/1234",
+		"PATCH /webhooks/id/token/messages/deleted",
+		"POST /webhooks/id/token",
+	}
+	if !slices.Equal(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
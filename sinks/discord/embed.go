@@ -0,0 +1,128 @@
+package discord
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+// Limits on embeds imposed by Discord.
+// See https://discord.com/developers/docs/resources/message#embed-object-embed-limits.
+const (
+	MaxEmbeds          = 10
+	MaxEmbedFields     = 25
+	MaxEmbedTitle      = 256
+	MaxEmbedFieldName  = 256
+	MaxEmbedFieldValue = 1024
+	MaxEmbedFooter     = 2048
+	MaxEmbedsTotal     = 6000
+)
+
+const (
+	// Color is the color of embeds for a session that is still going.
+	Color = 0x5865F2
+	// FinalColor is the color of embeds for a session that is over.
+	FinalColor = 0xF1C40F
+)
+
+type Embed struct {
+	Title     string       `json:"title,omitempty"`
+	Color     int          `json:"color,omitempty"`
+	Fields    []EmbedField `json:"fields,omitempty"`
+	Footer    *EmbedFooter `json:"footer,omitempty"`
+	Timestamp string       `json:"timestamp,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type EmbedFooter struct {
+	Text string `json:"text"`
+}
+
+func (e *Embed) len() int {
+	n := utf8.RuneCountInString(e.Title)
+	for _, field := range e.Fields {
+		n += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+
+	return n
+}
+
+// Embeds renders event's standings as Discord embeds, with a field for
+// each player, split across as many embeds as it takes to fit Discord's
+// limits. Standings that do not fit in a single message are left out.
+func Embeds(event *rvglutils.Event) []Embed {
+	var (
+		color = Color
+		title = truncate(fmt.Sprintf("%s, hosted by %s", event.Session.Mode, event.Session.Host), MaxEmbedTitle)
+		last  = Embed{}
+	)
+	if event.Final() {
+		color = FinalColor
+	}
+
+	if event.Race != nil {
+		last.Footer = &EmbedFooter{Text: truncate(fmt.Sprintf("Last track: %s", event.Race.Track), MaxEmbedFooter)}
+	}
+
+	if !event.Session.Date.IsZero() {
+		last.Timestamp = event.Session.Date.Format(time.RFC3339)
+	}
+
+	var (
+		embeds = []Embed{{Title: title, Color: color}}
+		total  = embeds[0].len() + last.len()
+	)
+
+	for _, standing := range event.Standings {
+		name := fmt.Sprintf("%s. %s", rvglutils.Ordinal(standing.Position), standing.Player)
+		if event.Final() && standing.Position == 1 {
+			name = fmt.Sprintf("WINNER! %s", standing.Player)
+		}
+
+		field := EmbedField{
+			Name:   truncate(name, MaxEmbedFieldName),
+			Value:  truncate(fmt.Sprintf("%g pts (%s)", standing.Points, rvglutils.FormatChange(standing.Change)), MaxEmbedFieldValue),
+			Inline: true,
+		}
+
+		n := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+		if total+n > MaxEmbedsTotal {
+			break
+		}
+
+		if len(embeds[len(embeds)-1].Fields) == MaxEmbedFields {
+			if len(embeds) == MaxEmbeds {
+				break
+			}
+
+			embeds = append(embeds, Embed{Color: color})
+		}
+
+		embeds[len(embeds)-1].Fields = append(embeds[len(embeds)-1].Fields, field)
+		total += n
+	}
+
+	embeds[len(embeds)-1].Footer = last.Footer
+	embeds[len(embeds)-1].Timestamp = last.Timestamp
+
+	return embeds
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
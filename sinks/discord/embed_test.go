@@ -0,0 +1,56 @@
+package discord_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/discord"
+)
+
+func TestEmbeds(t *testing.T) {
+	results := make([]rvglutils.Result, 30)
+	for i := range results {
+		results[i] = rvglutils.Result{Position: i + 1, Player: fmt.Sprintf("Player %d", i+1), Finished: true}
+	}
+
+	var (
+		session = &rvglutils.Session{
+			Date:  time.Date(2025, time.January, 1, 20, 0, 0, 0, time.UTC),
+			Host:  "frantjc",
+			Mode:  "Single Race",
+			Races: []rvglutils.Race{{Track: "Toys in the Hood 1", Results: results}},
+		}
+		event  = rvglutils.NewEvent(rvglutils.EventSessionFinal, session, &rvglutils.ScoreSessionOpts{IncludeAI: true})
+		embeds = discord.Embeds(event)
+	)
+
+	if len(embeds) != 2 {
+		t.Fatalf("expected 2 embeds, got %d", len(embeds))
+	}
+
+	if n := len(embeds[0].Fields) + len(embeds[1].Fields); n != len(results) {
+		t.Errorf("expected %d fields, got %d", len(results), n)
+	}
+
+	if embeds[0].Title != "Single Race, hosted by frantjc" {
+		t.Errorf("unexpected title %q", embeds[0].Title)
+	}
+
+	if embeds[0].Color != discord.FinalColor {
+		t.Errorf("expected final color, got %#x", embeds[0].Color)
+	}
+
+	if embeds[0].Fields[0].Name != "WINNER! Player 1" {
+		t.Errorf("unexpected first field %q", embeds[0].Fields[0].Name)
+	}
+
+	if embeds[1].Footer == nil || embeds[1].Footer.Text != "Last track: Toys in the Hood 1" {
+		t.Errorf("unexpected footer %v", embeds[1].Footer)
+	}
+
+	if embeds[1].Timestamp != "2025-01-01T20:00:00Z" {
+		t.Errorf("unexpected timestamp %q", embeds[1].Timestamp)
+	}
+}
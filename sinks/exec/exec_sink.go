@@ -0,0 +1,156 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "exec"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+	// maxStderr is how much of a failed command's stderr to include in its error.
+	maxStderr = 4096
+)
+
+// Sink runs a program on each update, writing the Event as JSON to its
+// stdin. The program's environment also describes the Event:
+//
+//   - RVGLSM_EVENT is its type, e.g. "RaceCompleted"
+//   - RVGLSM_FINAL is "true" if it is the last Event for the session
+//   - RVGLSM_RACE is the number of the latest race, if any
+//   - RVGLSM_SESSION_CSV is the path to the session .csv, if known
+//
+// The program's stdout is discarded, and its stderr is included
+// in the returned error if it fails or exceeds Timeout.
+type Sink struct {
+	Path string
+	Args []string
+	// Timeout is how long the program may run, DefaultTimeout if not positive.
+	Timeout time.Duration
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	stdin, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		cmd    = exec.CommandContext(ctx, s.Path, s.Args...)
+		stderr = new(bytes.Buffer)
+	)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = stderr
+	// Don't wait forever on the program's children to close its pipes after it is killed.
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"RVGLSM_EVENT="+string(event.Type),
+		"RVGLSM_FINAL="+strconv.FormatBool(event.Final()),
+		"RVGLSM_SESSION_CSV="+o.SessionCSV,
+	)
+	if event.RaceNumber > 0 {
+		cmd.Env = append(cmd.Env, "RVGLSM_RACE="+strconv.Itoa(event.RaceNumber))
+	}
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			if len(msg) > maxStderr {
+				msg = "..." + msg[len(msg)-maxStderr:]
+			}
+
+			return fmt.Errorf("run %s: %w: %s", filepath.Base(s.Path), err, msg)
+		}
+
+		return fmt.Errorf("run %s: %w", filepath.Base(s.Path), err)
+	}
+
+	return nil
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	var (
+		query = u.Query()
+		s     = &Sink{Args: query["arg"]}
+	)
+
+	switch {
+	// exec:program looks up program on $PATH.
+	case u.Opaque != "":
+		path, err := exec.LookPath(u.Opaque)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Path = path
+	case u.Host == "~":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		s.Path = filepath.Join(home, u.Path)
+	case u.Path != "" && u.Path != "/":
+		s.Path = filepath.Join(u.Host, u.Path)
+	default:
+		return nil, fmt.Errorf("exec sink requires a program")
+	}
+
+	if timeout := query.Get("timeout"); timeout != "" {
+		var err error
+		if s.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,92 @@
+package exec_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/exec"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+// TestMain lets the test binary stand in for the program that the Sink runs.
+func TestMain(m *testing.M) {
+	switch os.Getenv("EXEC_SINK_TEST_HELPER") {
+	case "":
+		os.Exit(m.Run())
+	case "echo":
+		event := &rvglutils.Event{}
+		if err := json.NewDecoder(os.Stdin).Decode(event); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(os.Args[1], fmt.Appendf(nil, "%s %s %s %d", os.Getenv("RVGLSM_EVENT"), os.Getenv("RVGLSM_FINAL"), os.Getenv("RVGLSM_RACE"), len(event.Standings)), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "fail":
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		fmt.Fprintln(os.Stderr, "something went wrong")
+		os.Exit(1)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+
+	os.Exit(0)
+}
+
+func newSink(t *testing.T, helper string, args ...string) *exec.Sink {
+	t.Helper()
+	t.Setenv("EXEC_SINK_TEST_HELPER", helper)
+
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("find test executable: %v", err)
+	}
+
+	return &exec.Sink{Path: path, Args: args}
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		name = filepath.Join(t.TempDir(), "out")
+		s    = newSink(t, "echo", name)
+	)
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+
+	if expected := fmt.Sprintf("SessionFinal true %d %d", len(session.Races), len(rvglutils.Standings(session))); string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	if err := newSink(t, "fail").UpdateSession(t.Context(), &rvglutils.Session{}); err == nil || !strings.Contains(err.Error(), "something went wrong") {
+		t.Errorf("expected error with stderr, got %v", err)
+	}
+}
+
+func TestSinkTimeout(t *testing.T) {
+	s := newSink(t, "hang")
+	s.Timeout = 100 * time.Millisecond
+
+	if err := s.UpdateSession(t.Context(), &rvglutils.Session{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "file"
+)
+
+// Sink replaces the file at Path with the standings in Format, or rendered
+// with Template if set, on each update. The file is replaced atomically,
+// so readers never see a partial update.
+type Sink struct {
+	Path     string
+	Format   rvglutils.Format
+	Template *template.Template
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(_ context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	var (
+		o   = new(rvglutils.UpdateSessionOpts)
+		buf = new(bytes.Buffer)
+	)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	tmpl := s.Template
+	if tmpl == nil && s.Format == "" {
+		tmpl = o.Template
+	}
+
+	if tmpl != nil {
+		if err := tmpl.Execute(buf, event); err != nil {
+			return err
+		}
+	} else if err := rvglutils.EncodeEvent(buf, s.Format, event); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), "."+filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("file sink requires a path")
+	}
+
+	path := u.Path
+
+	switch u.Host {
+	case "~":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(home, path)
+	default:
+		path = filepath.Join(u.Host, path)
+	}
+
+	var (
+		s     = &Sink{Path: path}
+		query = u.Query()
+		err   error
+	)
+
+	if text := query.Get("template"); text != "" {
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = filepath.Ext(path)
+	}
+
+	if s.Format, err = rvglutils.ParseFormat(format); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,134 @@
+package file_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"text/template"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/file"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestSinkFormat(t *testing.T) {
+	var (
+		session = testdata.Session(t)
+		event   = rvglutils.NewUpdateEvent(session)
+		dir     = t.TempDir()
+	)
+
+	for name, format := range map[string]rvglutils.Format{
+		"standings.json":            rvglutils.FormatJSON,
+		"standings.csv":             rvglutils.FormatCSV,
+		"standings.md":              rvglutils.FormatMarkdown,
+		"standings.html":            rvglutils.FormatHTML,
+		"standings.txt?format=csv":  rvglutils.FormatCSV,
+		"standings.json?format=md":  rvglutils.FormatMarkdown,
+		"standings?format=markdown": rvglutils.FormatMarkdown,
+	} {
+		t.Run(name, func(t *testing.T) {
+			sink, err := rvglutils.OpenSink(t.Context(), "file://"+filepath.ToSlash(filepath.Join(dir, name)))
+			if err != nil {
+				t.Fatalf("open sink: %v", err)
+			}
+
+			s := sink.(*file.Sink)
+			if s.Format != format {
+				t.Fatalf("expected format %q, got %q", format, s.Format)
+			}
+
+			if err := s.UpdateSession(t.Context(), session); err != nil {
+				t.Fatalf("update session: %v", err)
+			}
+
+			b, err := os.ReadFile(s.Path)
+			if err != nil {
+				t.Fatalf("read %q: %v", s.Path, err)
+			}
+
+			expected := new(bytes.Buffer)
+			if err := rvglutils.EncodeEvent(expected, format, event); err != nil {
+				t.Fatalf("encode event: %v", err)
+			}
+
+			if !bytes.Equal(b, expected.Bytes()) {
+				t.Errorf("expected %q, got %q", expected, b)
+			}
+		})
+	}
+}
+
+func TestSinkInvalidFormat(t *testing.T) {
+	if _, err := rvglutils.OpenSink(t.Context(), "file://"+filepath.ToSlash(filepath.Join(t.TempDir(), "standings.txt"))); err == nil {
+		t.Fatal("expected error opening sink with unknown extension")
+	}
+}
+
+func TestSinkReplacesAtomically(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		s   = &file.Sink{
+			Path:     filepath.Join(dir, "standings.txt"),
+			Template: parseTemplate(t, "{{ len .Session.Races }}"),
+		}
+		session = testdata.Session(t)
+		before  = *session
+	)
+	before.Races = session.Races[:1]
+
+	if err := s.UpdateSession(t.Context(), &before); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	// A reader with the file open while it is replaced keeps
+	// seeing the whole of the old file rather than a partial one.
+	f, err := os.Open(s.Path)
+	if err != nil {
+		t.Fatalf("open %q: %v", s.Path, err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if err := s.UpdateSession(t.Context(), session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if b, err := io.ReadAll(f); err != nil {
+		t.Fatalf("read old %q: %v", s.Path, err)
+	} else if string(b) != "1" {
+		t.Errorf("expected old file to be untouched, got %q", b)
+	}
+
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatalf("read %q: %v", s.Path, err)
+	}
+
+	if expected := strconv.Itoa(len(session.Races)); string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir %q: %v", dir, err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected only %q in %q, got %d entries", filepath.Base(s.Path), dir, len(entries))
+	}
+}
+
+func parseTemplate(t *testing.T, text string) *template.Template {
+	t.Helper()
+
+	tmpl, err := rvglutils.ParseTemplate(text)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	return tmpl
+}
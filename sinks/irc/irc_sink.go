@@ -0,0 +1,548 @@
+package irc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme, SchemeTLS)
+}
+
+const (
+	Scheme    = "irc"
+	SchemeTLS = "ircs"
+)
+
+const (
+	DefaultNick       = "rvglsm"
+	DefaultInterval   = 2 * time.Second
+	DefaultBurst      = 4
+	DefaultMaxRetries = 5
+	DefaultBackoff    = time.Second
+	// MaxMessageText is how long the text of a message can be, leaving room
+	// for the rest of the line within IRC's limit of 512 bytes.
+	MaxMessageText = 400
+)
+
+// Sink posts standings to an IRC channel: a one-line summary after each
+// race and a full table when the session is over. It connects lazily on the
+// first update and reconnects whenever the connection is lost.
+type Sink struct {
+	// Addr is the host:port of the IRC server.
+	Addr string
+	// TLSConfig is used to connect to the server over TLS, if set.
+	TLSConfig *tls.Config
+	Nick      string
+	Password  string
+	Channel   string
+	Template  *template.Template
+	// Interval is how long each message adds to the wait before the next,
+	// DefaultInterval if not positive.
+	Interval time.Duration
+	// Burst is how many messages can be sent before waiting for Interval,
+	// DefaultBurst if not positive.
+	Burst int
+	// MaxRetries is the number of times to retry connecting to the server,
+	// DefaultMaxRetries if not positive.
+	MaxRetries int
+	// Backoff is how long to wait before the first retry of connecting to
+	// the server, DefaultBackoff if not positive. It doubles with each retry.
+	Backoff time.Duration
+
+	// mu serializes sending messages, so it is held while waiting on the
+	// throttle or to reconnect. read answers PINGs while it is held, so
+	// writing to conn is guarded by writeMu instead.
+	mu      sync.Mutex
+	writeMu sync.Mutex
+	conn    *textproto.Conn
+	lost    chan struct{}
+	nick    string
+	penalty time.Time
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	var lines []string
+
+	if tmpl != nil {
+		text := strings.Builder{}
+
+		if err := tmpl.Execute(&text, event); err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(text.String()), "\n") {
+			lines = append(lines, wrap(strings.TrimRight(line, "\r"), MaxMessageText)...)
+		}
+	} else {
+		lines = Lines(event)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for reconnects := 0; len(lines) > 0; {
+		if s.conn == nil {
+			if err := s.connect(ctx); err != nil {
+				return err
+			}
+		}
+
+		line := lines[0]
+		if line == "" {
+			lines = lines[1:]
+			continue
+		}
+
+		if err := s.throttle(ctx); err != nil {
+			return err
+		}
+
+		if err := s.printfLine(s.conn, "PRIVMSG %s :%s", s.Channel, line); err != nil {
+			s.disconnect()
+
+			// Reconnect and try the line again.
+			if reconnects++; reconnects > s.maxRetries() {
+				return err
+			}
+
+			continue
+		}
+
+		lines = lines[1:]
+	}
+
+	return nil
+}
+
+// Lines renders event as lines of an IRC message: a summary of the standings
+// for most events, or a table of them if final. Summaries too long for one
+// message are split between standings across as many lines as they need.
+func Lines(event *rvglutils.Event) []string {
+	switch {
+	case event.Final():
+		lines := []string{fmt.Sprintf("Final standings: %s, hosted by %s", event.Session.Mode, event.Session.Host)}
+
+		width := 0
+		for _, standing := range event.Standings {
+			width = max(width, len(standing.Player))
+		}
+
+		for _, standing := range event.Standings {
+			lines = append(lines, fmt.Sprintf("%-4s %-*s %g", rvglutils.Ordinal(standing.Position), width, standing.Player, standing.Points))
+		}
+
+		return lines
+	case event.Type == rvglutils.EventSessionStarted && event.Race == nil:
+		return []string{fmt.Sprintf("Session started: %s, hosted by %s", event.Session.Mode, event.Session.Host)}
+	}
+
+	line := "Standings:"
+	if event.Race != nil {
+		line = fmt.Sprintf("Race %d (%s):", event.RaceNumber, event.Race.Track)
+	}
+
+	for i, standing := range event.Standings {
+		if i > 0 {
+			line += ","
+		}
+
+		line += fmt.Sprintf(" %d. %s %g (%s)", standing.Position, standing.Player, standing.Points, rvglutils.FormatChange(standing.Change))
+	}
+
+	return wrap(line, MaxMessageText)
+}
+
+// throttle waits so that messages are not sent faster than
+// a burst of Burst and then one each Interval after that.
+func (s *Sink) throttle(ctx context.Context) error {
+	var (
+		interval = s.Interval
+		burst    = s.Burst
+		now      = time.Now()
+	)
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+
+	if s.penalty.Before(now) {
+		s.penalty = now
+	}
+
+	if wait := s.penalty.Sub(now) - time.Duration(burst-1)*interval; wait > 0 {
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+
+	s.penalty = s.penalty.Add(interval)
+
+	return nil
+}
+
+func (s *Sink) maxRetries() int {
+	if s.MaxRetries <= 0 {
+		return DefaultMaxRetries
+	}
+
+	return s.MaxRetries
+}
+
+// connect connects to the server, retrying with exponential backoff.
+func (s *Sink) connect(ctx context.Context) error {
+	var (
+		maxRetries = s.maxRetries()
+		backoff    = s.Backoff
+	)
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.dial(ctx)
+		if err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		} else if attempt >= maxRetries {
+			return fmt.Errorf("connect to %s: %w", s.Addr, err)
+		}
+
+		if err := sleep(ctx, backoff<<attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// dial connects to the server, registers and joins Channel.
+func (s *Sink) dial(ctx context.Context) error {
+	var (
+		netConn net.Conn
+		err     error
+	)
+	if s.TLSConfig != nil {
+		netConn, err = (&tls.Dialer{Config: s.TLSConfig}).DialContext(ctx, "tcp", s.Addr)
+	} else {
+		netConn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Don't wait on the server forever to register and join.
+	deadline := time.Now().Add(30 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = netConn.SetDeadline(deadline)
+
+	conn := textproto.NewConn(netConn)
+
+	if err := s.register(conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	_ = netConn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	s.lost = make(chan struct{})
+	go s.read(conn, s.lost)
+
+	return nil
+}
+
+func (s *Sink) register(conn *textproto.Conn) error {
+	s.nick = s.Nick
+	if s.nick == "" {
+		s.nick = DefaultNick
+	}
+
+	if s.Password != "" {
+		if err := conn.PrintfLine("PASS %s", s.Password); err != nil {
+			return err
+		}
+	}
+
+	if err := conn.PrintfLine("NICK %s", s.nick); err != nil {
+		return err
+	}
+
+	if err := conn.PrintfLine("USER %s 0 * :rvglsm", s.nick); err != nil {
+		return err
+	}
+
+	joined := false
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		msg := parse(line)
+
+		switch msg.command {
+		case "PING":
+			if err := conn.PrintfLine("PONG :%s", msg.trailing()); err != nil {
+				return err
+			}
+		case "433": // ERR_NICKNAMEINUSE
+			s.nick += "_"
+
+			if err := conn.PrintfLine("NICK %s", s.nick); err != nil {
+				return err
+			}
+		case "001": // RPL_WELCOME
+			if !joined {
+				joined = true
+
+				if err := conn.PrintfLine("JOIN %s", s.Channel); err != nil {
+					return err
+				}
+			}
+		case "JOIN":
+			if strings.EqualFold(msg.nick(), s.nick) {
+				return nil
+			}
+		case "ERROR":
+			return fmt.Errorf("server closed connection: %s", msg.trailing())
+		case "403", "405", "471", "473", "474", "475": // Couldn't join the channel.
+			return fmt.Errorf("join %s: %s", s.Channel, msg.trailing())
+		}
+	}
+}
+
+// read answers the server's PINGs until the connection is lost.
+func (s *Sink) read(conn *textproto.Conn, lost chan struct{}) {
+	defer close(lost)
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			break
+		}
+
+		msg := parse(line)
+
+		switch msg.command {
+		case "PING":
+			_ = s.printfLine(conn, "PONG :%s", msg.trailing())
+		case "ERROR":
+			_ = conn.Close()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+func (s *Sink) printfLine(conn *textproto.Conn, format string, args ...any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return conn.PrintfLine(format, args...)
+}
+
+func (s *Sink) disconnect() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// Close implements io.Closer.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+
+	conn, lost := s.conn, s.lost
+	if conn == nil {
+		s.mu.Unlock()
+		return nil
+	}
+
+	err := s.printfLine(conn, "QUIT :rvglsm")
+	s.mu.Unlock()
+
+	// Give the server a moment to close the connection on its end.
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+	}
+
+	return errors.Join(err, conn.Close())
+}
+
+type message struct {
+	prefix  string
+	command string
+	params  []string
+}
+
+func (m *message) nick() string {
+	nick, _, _ := strings.Cut(m.prefix, "!")
+	return nick
+}
+
+func (m *message) trailing() string {
+	if len(m.params) == 0 {
+		return ""
+	}
+
+	return m.params[len(m.params)-1]
+}
+
+func parse(line string) *message {
+	msg := &message{}
+
+	if rest, ok := strings.CutPrefix(line, ":"); ok {
+		msg.prefix, line, _ = strings.Cut(rest, " ")
+	}
+
+	line, trailing, hasTrailing := strings.Cut(line, " :")
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		msg.command = strings.ToUpper(fields[0])
+		msg.params = fields[1:]
+	}
+
+	if hasTrailing {
+		msg.params = append(msg.params, trailing)
+	}
+
+	return msg
+}
+
+// wrap splits line into lines of at most n bytes, breaking it after
+// the commas between standings where possible and between runes if not.
+func wrap(line string, n int) []string {
+	lines := []string{}
+
+	for len(line) > n {
+		if i := strings.LastIndex(line[:n], ", "); i > 0 {
+			lines = append(lines, line[:i+1])
+			line = line[i+2:]
+			continue
+		}
+
+		i := n
+		for i > 0 && !isRuneStart(line[i]) {
+			i--
+		}
+
+		lines = append(lines, line[:i])
+		line = line[i:]
+	}
+
+	return append(lines, line)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	port := "6667"
+
+	s := &Sink{
+		Nick:    u.User.Username(),
+		Channel: strings.TrimPrefix(u.Path, "/"),
+	}
+
+	switch u.Scheme {
+	case Scheme:
+	case SchemeTLS:
+		port = "6697"
+		s.TLSConfig = &tls.Config{ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("invalid scheme %q, expected %q or %q", u.Scheme, Scheme, SchemeTLS)
+	}
+
+	s.Password, _ = u.User.Password()
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("irc sink requires a host")
+	}
+
+	s.Addr = u.Host
+	if u.Port() == "" {
+		s.Addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	// "#" begins a URL's fragment, so the channel can be given
+	// either as irc://host/channel or as irc://host/#channel.
+	if s.Channel == "" {
+		s.Channel = u.Fragment
+	}
+
+	if s.Channel == "" {
+		return nil, fmt.Errorf("irc sink requires a channel")
+	}
+
+	if !strings.ContainsAny(s.Channel[:1], "#&+!") {
+		s.Channel = "#" + s.Channel
+	}
+
+	if text := u.Query().Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
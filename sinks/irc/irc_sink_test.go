@@ -0,0 +1,239 @@
+package irc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/irc"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+// server is a minimal in-process IRC server that records the PRIVMSGs it receives.
+type server struct {
+	net.Listener
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	messages chan string
+}
+
+func newServer(t *testing.T) *server {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	srv := &server{Listener: l, messages: make(chan string, 64)}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			srv.mu.Lock()
+			srv.conns = append(srv.conns, conn)
+			srv.mu.Unlock()
+
+			go srv.serve(conn)
+		}
+	}()
+
+	return srv
+}
+
+func (srv *server) serve(netConn net.Conn) {
+	var (
+		conn = textproto.NewConn(netConn)
+		nick string
+	)
+	defer conn.Close() //nolint:errcheck
+
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, params, _ := strings.Cut(line, " ")
+
+		switch command {
+		case "NICK":
+			nick = params
+		case "USER":
+			_ = conn.PrintfLine(":irc.test 001 %s :Welcome", nick)
+		case "JOIN":
+			_ = conn.PrintfLine(":%s!rvglsm@localhost JOIN %s", nick, params)
+		case "PRIVMSG":
+			srv.messages <- params
+		case "QUIT":
+			srv.messages <- line
+			return
+		}
+	}
+}
+
+// drop closes every connection to the server.
+func (srv *server) drop() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, conn := range srv.conns {
+		_ = conn.Close()
+	}
+}
+
+func (srv *server) receive(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case message := <-srv.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+		return ""
+	}
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	srv := newServer(t)
+
+	sink, err := rvglutils.OpenSink(context.Background(), "irc://rvglsm@"+srv.Addr().String()+"/rvgl")
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+
+	s := sink.(*irc.Sink)
+	s.Interval = 10 * time.Millisecond
+	s.Backoff = 10 * time.Millisecond
+
+	if err := s.HandleEvent(t.Context(), rvglutils.NewEvent(rvglutils.EventRaceCompleted, session)); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+
+	if message := srv.receive(t); !strings.HasPrefix(message, "#rvgl :Race ") {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	srv.drop()
+
+	// The sink may not notice that the connection was lost
+	// until it next writes to it, so keep updating until it does.
+	var message string
+	for message == "" {
+		if err := s.HandleEvent(t.Context(), rvglutils.NewEvent(rvglutils.EventRaceCompleted, session)); err != nil {
+			t.Fatalf("handle event: %v", err)
+		}
+
+		select {
+		case message = <-srv.messages:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	final := rvglutils.NewEvent(rvglutils.EventSessionFinal, session)
+	if err := s.HandleEvent(t.Context(), final); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+
+	for _, line := range irc.Lines(final) {
+		if message := srv.receive(t); message != "#rvgl :"+line {
+			t.Errorf("expected %q, got %q", "#rvgl :"+line, message)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if message := srv.receive(t); !strings.HasPrefix(message, "QUIT") {
+		t.Errorf("expected QUIT, got %q", message)
+	}
+}
+
+func TestSinkThrottle(t *testing.T) {
+	srv := newServer(t)
+
+	var (
+		s = &irc.Sink{
+			Addr:     srv.Addr().String(),
+			Channel:  "#rvgl",
+			Template: parseTemplate(t, "1\n2\n3\n4"),
+			Interval: 50 * time.Millisecond,
+			Burst:    2,
+		}
+		start = time.Now()
+	)
+	defer s.Close() //nolint:errcheck
+
+	if err := s.UpdateSession(t.Context(), &rvglutils.Session{}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	// The first 2 messages go out at once, then one every 50ms.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected sending to take at least 100ms, took %s", elapsed)
+	}
+}
+
+func parseTemplate(t *testing.T, text string) *template.Template {
+	t.Helper()
+
+	tmpl, err := rvglutils.ParseTemplate(text)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	return tmpl
+}
+
+func TestLines(t *testing.T) {
+	event := &rvglutils.Event{
+		Type:       rvglutils.EventRaceCompleted,
+		Session:    &rvglutils.Session{},
+		Race:       &rvglutils.Race{Track: "Toys in the Hood 1"},
+		RaceNumber: 1,
+	}
+	for i := range 20 {
+		event.Standings = append(event.Standings, rvglutils.Standing{
+			Score:    rvglutils.Score{Player: strings.Repeat(string(rune('A'+i)), 20), Points: float64(20 - i)},
+			Position: i + 1,
+		})
+	}
+
+	lines := irc.Lines(event)
+	if len(lines) < 2 {
+		t.Fatalf("expected long summary to be split, got %q", lines)
+	}
+
+	joined := strings.Join(lines, " ")
+	for _, standing := range event.Standings {
+		if !strings.Contains(joined, fmt.Sprintf("%d. %s %g", standing.Position, standing.Player, standing.Points)) {
+			t.Errorf("expected standing of %s in %q", standing.Player, lines)
+		}
+	}
+
+	for _, line := range lines {
+		if len(line) > irc.MaxMessageText {
+			t.Errorf("expected line of at most %d bytes, got %d", irc.MaxMessageText, len(line))
+		}
+
+		if !strings.HasSuffix(line, ")") && !strings.HasSuffix(line, "),") {
+			t.Errorf("expected line to end between standings, got %q", line)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "matrix"
+)
+
+const (
+	DefaultMaxRetries = 5
+)
+
+// Sink posts standings to a Matrix room as an HTML table and then keeps
+// them up to date by editing that event with m.replace relations.
+type Sink struct {
+	// Homeserver is the base URL of the homeserver, e.g. "https://matrix.org".
+	Homeserver  string
+	AccessToken string
+	// Room is the room's ID, e.g. "!abc:matrix.org", or an alias
+	// for it, e.g. "#rvgl:matrix.org", which is resolved on first use.
+	Room string
+	// EventID is the ID of the event holding the standings, if one was sent.
+	EventID    string
+	HTTPClient *http.Client
+	Template   *template.Template
+	// MaxRetries is the number of times to retry a rate limited
+	// request, DefaultMaxRetries if not positive.
+	MaxRetries int
+
+	roomID string
+	txn    atomic.Int64
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	content := map[string]any{"msgtype": "m.notice"}
+
+	if tmpl != nil {
+		text := strings.Builder{}
+
+		if err := tmpl.Execute(&text, event); err != nil {
+			return err
+		}
+
+		content["body"] = text.String()
+	} else {
+		var plain, html bytes.Buffer
+
+		if err := rvglutils.EncodeEvent(&plain, rvglutils.FormatMarkdown, event); err != nil {
+			return err
+		}
+
+		if err := rvglutils.EncodeEvent(&html, rvglutils.FormatHTML, event); err != nil {
+			return err
+		}
+
+		content["body"] = plain.String()
+		content["format"] = "org.matrix.custom.html"
+		content["formatted_body"] = html.String()
+	}
+
+	if s.roomID == "" {
+		if err := s.resolveRoom(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Pick up editing the event from a previous run against the same session.
+	stateKey := ""
+	if o.State != nil && o.SessionCSV != "" {
+		stateKey = fmt.Sprintf("%s:%s:%s", Scheme, s.roomID, o.SessionCSV)
+
+		if s.EventID == "" {
+			eventID, _, err := o.State.Load(stateKey)
+			if err != nil {
+				return fmt.Errorf("load event ID: %w", err)
+			}
+
+			s.EventID = eventID
+		}
+	}
+
+	body := content
+	if s.EventID != "" {
+		body = map[string]any{
+			"m.new_content": content,
+			"m.relates_to": map[string]any{
+				"rel_type": "m.replace",
+				"event_id": s.EventID,
+			},
+		}
+
+		// Clients that do not support edits show these as is.
+		for key, value := range content {
+			body[key] = value
+		}
+		body["body"] = "* " + content["body"].(string)
+		if formattedBody, ok := content["formatted_body"].(string); ok {
+			body["formatted_body"] = "* " + formattedBody
+		}
+	}
+
+	data := struct {
+		EventID string `json:"event_id"`
+	}{}
+
+	txnID := fmt.Sprintf("rvglsm.%d.%d", time.Now().UnixNano(), s.txn.Add(1))
+
+	if err := s.do(ctx, http.MethodPut, fmt.Sprintf("rooms/%s/send/m.room.message/%s", url.PathEscape(s.roomID), txnID), body, &data); err != nil {
+		return err
+	}
+
+	if s.EventID == "" {
+		if data.EventID == "" {
+			return fmt.Errorf("missing event ID in successful response")
+		}
+
+		s.EventID = data.EventID
+
+		if stateKey != "" {
+			if err := o.State.Store(stateKey, s.EventID); err != nil {
+				return fmt.Errorf("store event ID: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveRoom resolves Room to a room ID if it is an alias.
+func (s *Sink) resolveRoom(ctx context.Context) error {
+	if !strings.HasPrefix(s.Room, "#") {
+		s.roomID = s.Room
+		return nil
+	}
+
+	data := struct {
+		RoomID string `json:"room_id"`
+	}{}
+
+	if err := s.do(ctx, http.MethodGet, "directory/room/"+url.PathEscape(s.Room), nil, &data); err != nil {
+		return fmt.Errorf("resolve room %s: %w", s.Room, err)
+	}
+
+	s.roomID = data.RoomID
+
+	return nil
+}
+
+// Error is an error response from a homeserver.
+type Error struct {
+	StatusCode   int    `json:"-"`
+	ErrCode      string `json:"errcode"`
+	Err          string `json:"error"`
+	RetryAfterMS int64  `json:"retry_after_ms"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrCode, e.Err)
+}
+
+// do sends a request to the client-server API, retrying it
+// after as long as the homeserver asks if it is rate limited.
+func (s *Sink) do(ctx context.Context, method, path string, in, out any) error {
+	if s.HTTPClient == nil {
+		s.HTTPClient = http.DefaultClient
+	}
+
+	maxRetries := s.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(s.Homeserver, "/")+"/_matrix/client/v3/"+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+s.AccessToken)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		res, err := s.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		b, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			return json.Unmarshal(b, out)
+		}
+
+		matrixErr := &Error{StatusCode: res.StatusCode}
+		if json.Unmarshal(b, matrixErr) != nil || matrixErr.ErrCode == "" {
+			return fmt.Errorf("%s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(b)))
+		}
+
+		if res.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+			return matrixErr
+		}
+
+		t := time.NewTimer(time.Duration(matrixErr.RetryAfterMS) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	var (
+		query = u.Query()
+		s     = &Sink{
+			Homeserver:  (&url.URL{Scheme: "https", Host: u.Host}).String(),
+			AccessToken: u.User.Username(),
+			Room:        strings.TrimPrefix(u.Path, "/"),
+		}
+	)
+
+	// "#" begins a URL's fragment, so a room alias can
+	// be given as matrix://token@host/#rvgl:host.
+	if s.Room == "" && u.Fragment != "" {
+		s.Room = "#" + u.Fragment
+	}
+
+	if s.AccessToken == "" || u.Host == "" || s.Room == "" {
+		return nil, fmt.Errorf("matrix sink requires an access token, homeserver and room")
+	}
+
+	if query.Get("insecure") == "true" {
+		s.Homeserver = (&url.URL{Scheme: "http", Host: u.Host}).String()
+	}
+
+	if text := query.Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
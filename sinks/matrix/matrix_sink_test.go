@@ -0,0 +1,74 @@
+package matrix_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frantjc/rvgl-utils/sinks/matrix"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		limited = false
+		sent    = []map[string]any{}
+		srv     = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+			}
+
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/_matrix/client/v3/directory/room/#rvgl:localhost":
+				_, _ = w.Write([]byte(`{"room_id":"!room:localhost"}`))
+			case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!room:localhost/send/m.room.message/"):
+				if !limited {
+					limited = true
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","error":"Too many requests","retry_after_ms":1}`))
+					return
+				}
+
+				content := map[string]any{}
+				if err := json.NewDecoder(r.Body).Decode(&content); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+
+				sent = append(sent, content)
+				_, _ = w.Write([]byte(`{"event_id":"$event"}`))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		s = &matrix.Sink{Homeserver: srv.URL, AccessToken: "token", Room: "#rvgl:localhost"}
+	)
+	defer srv.Close()
+
+	for range 2 {
+		if err := s.UpdateSession(t.Context(), session); err != nil {
+			t.Fatalf("update session: %v", err)
+		}
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(sent))
+	}
+
+	if formattedBody, _ := sent[0]["formatted_body"].(string); !strings.Contains(formattedBody, "<table>") {
+		t.Errorf("expected an HTML table, got %q", formattedBody)
+	}
+
+	relatesTo, _ := sent[1]["m.relates_to"].(map[string]any)
+	if relatesTo["rel_type"] != "m.replace" || relatesTo["event_id"] != "$event" {
+		t.Errorf("expected an edit of $event, got %v", relatesTo)
+	}
+
+	if _, ok := sent[1]["m.new_content"]; !ok {
+		t.Error("expected m.new_content in edit")
+	}
+}
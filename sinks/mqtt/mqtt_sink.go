@@ -0,0 +1,189 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme, SchemeTLS)
+}
+
+const (
+	Scheme    = "mqtt"
+	SchemeTLS = "mqtts"
+)
+
+const (
+	DefaultTopic = "rvgl/session"
+)
+
+// Sink publishes each Event as JSON to an MQTT broker. The messages are
+// retained by default, so dashboards that subscribe later still see the
+// latest standings. Under Topic, it publishes:
+//
+//   - "standings", the standings as of the latest Event
+//   - "event", the latest Event itself
+//   - "race/<n>", the Event for the nth race once it completes
+type Sink struct {
+	// Options are used to connect to the broker on the first update.
+	Options *paho.ClientOptions
+	// Topic is the prefix of the topics that are published to, DefaultTopic if empty.
+	Topic  string
+	QoS    byte
+	Retain bool
+
+	mu     sync.Mutex
+	client paho.Client
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		client := paho.NewClient(s.Options)
+
+		if err := wait(ctx, client.Connect()); err != nil {
+			return fmt.Errorf("connect to broker: %w", err)
+		}
+
+		s.client = client
+	}
+
+	topic := s.Topic
+	if topic == "" {
+		topic = DefaultTopic
+	}
+
+	messages := map[string]any{
+		topic + "/standings": event.Standings,
+		topic + "/event":     event,
+	}
+	if event.Type == rvglutils.EventRaceCompleted && event.Race != nil {
+		messages[fmt.Sprintf("%s/race/%d", topic, event.RaceNumber)] = event
+	}
+
+	for name, v := range messages {
+		payload, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		if err := wait(ctx, s.client.Publish(name, s.QoS, s.Retain, payload)); err != nil {
+			return fmt.Errorf("publish to %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Close implements io.Closer.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		s.client.Disconnect(250)
+		s.client = nil
+	}
+
+	return nil
+}
+
+func wait(ctx context.Context, token paho.Token) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-token.Done():
+		return token.Error()
+	}
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	var (
+		broker = &url.URL{Scheme: "tcp", Host: u.Host}
+		port   = "1883"
+		query  = u.Query()
+		s      = &Sink{
+			Options: paho.NewClientOptions(),
+			Topic:   strings.Trim(u.Path, "/"),
+			Retain:  true,
+		}
+	)
+
+	switch u.Scheme {
+	case Scheme:
+	case SchemeTLS:
+		broker.Scheme = "ssl"
+		port = "8883"
+		s.Options.SetTLSConfig(&tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("invalid scheme %q, expected %q or %q", u.Scheme, Scheme, SchemeTLS)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("mqtt sink requires a broker host")
+	}
+
+	if u.Port() == "" {
+		broker.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	clientID := query.Get("client_id")
+	if clientID == "" {
+		clientID = fmt.Sprintf("rvglsm-%d", time.Now().UnixNano())
+	}
+
+	s.Options.
+		AddBroker(broker.String()).
+		SetClientID(clientID).
+		SetAutoReconnect(true).
+		SetConnectTimeout(30 * time.Second)
+
+	if u.User != nil {
+		s.Options.SetUsername(u.User.Username())
+
+		if password, ok := u.User.Password(); ok {
+			s.Options.SetPassword(password)
+		}
+	}
+
+	if qos := query.Get("qos"); qos != "" {
+		n, err := strconv.Atoi(qos)
+		if err != nil || n < 0 || n > 2 {
+			return nil, fmt.Errorf("invalid qos %q, expected 0, 1 or 2", qos)
+		}
+
+		s.QoS = byte(n)
+	}
+
+	if retain := query.Get("retain"); retain != "" {
+		var err error
+		if s.Retain, err = strconv.ParseBool(retain); err != nil {
+			return nil, fmt.Errorf("invalid retain %q: %w", retain, err)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,108 @@
+package mqtt_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+func newBroker(t *testing.T) string {
+	t.Helper()
+
+	var (
+		broker = mochi.New(&mochi.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+		tcp    = listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	)
+
+	if err := broker.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("add hook: %v", err)
+	}
+
+	if err := broker.AddListener(tcp); err != nil {
+		t.Fatalf("add listener: %v", err)
+	}
+
+	if err := broker.Serve(); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	t.Cleanup(func() { _ = broker.Close() })
+
+	return tcp.Address()
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	addr := newBroker(t)
+
+	s, err := rvglutils.OpenSink(t.Context(), "mqtt://"+addr+"/rvgl/session?qos=1")
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+	defer rvglutils.CloseSink(t.Context(), s) //nolint:errcheck
+
+	if err := rvglutils.AsEventSink(s).HandleEvent(t.Context(), rvglutils.NewEvent(rvglutils.EventRaceCompleted, session)); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+
+	// The messages are retained, so a subscriber that comes along later still gets them.
+	var (
+		mu       sync.Mutex
+		messages = map[string][]byte{}
+		received = make(chan struct{}, 8)
+		client   = paho.NewClient(paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID("subscriber"))
+	)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		t.Fatalf("connect: %v", token.Error())
+	}
+	defer client.Disconnect(0)
+
+	if token := client.Subscribe("rvgl/session/#", 1, func(_ paho.Client, msg paho.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !msg.Retained() {
+			t.Errorf("expected %s to be retained", msg.Topic())
+		}
+
+		messages[msg.Topic()] = msg.Payload()
+		received <- struct{}{}
+	}); token.Wait() && token.Error() != nil {
+		t.Fatalf("subscribe: %v", token.Error())
+	}
+
+	for range 3 {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for retained messages")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	standings := []rvglutils.Standing{}
+	if err := json.Unmarshal(messages["rvgl/session/standings"], &standings); err != nil {
+		t.Fatalf("decode standings: %v", err)
+	} else if len(standings) == 0 {
+		t.Error("expected standings")
+	}
+
+	for _, topic := range []string{"rvgl/session/event", fmt.Sprintf("rvgl/session/race/%d", len(session.Races))} {
+		if _, ok := messages[topic]; !ok {
+			t.Errorf("expected message on %s", topic)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package overlay
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "overlay"
+)
+
+const (
+	DefaultAddr = ":8080"
+	// HeartbeatInterval is how often a comment is sent to idle
+	// event streams to keep proxies from closing them.
+	HeartbeatInterval = 15 * time.Second
+)
+
+var (
+	//go:embed index.html
+	indexHTML []byte
+	//go:embed theme.css
+	themeCSS []byte
+)
+
+// Sink serves a leaderboard page meant for an OBS browser source that
+// updates live with each update. It serves:
+//
+//   - "/", the page
+//   - "/theme.css", the page's stylesheet, read from Theme if set
+//   - "/standings.json", the latest Event as JSON
+//   - "/events", a stream of Server-Sent Events named "standings",
+//     each holding an Event as JSON, starting with the latest one
+type Sink struct {
+	// Theme is the path to a stylesheet to serve in place of the default one.
+	// It is read on each request, so it can be edited while streaming.
+	Theme string
+
+	mu          sync.Mutex
+	latest      []byte
+	subscribers map[chan []byte]struct{}
+	// server is set if the Sink was opened from a URL,
+	// rather than used as an http.Handler directly.
+	server *http.Server
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(_ context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latest = b
+
+	for ch := range s.subscribers {
+		// Subscribers only need the latest standings,
+		// so replace any that they have yet to receive.
+		select {
+		case <-ch:
+		default:
+		}
+
+		ch <- b
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/", "/index.html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexHTML)
+	case "/theme.css":
+		css := themeCSS
+		if s.Theme != "" {
+			var err error
+			if css, err = os.ReadFile(s.Theme); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(css)
+	case "/standings.json":
+		s.mu.Lock()
+		latest := s.latest
+		s.mu.Unlock()
+
+		if latest == nil {
+			latest = []byte("{}")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write(latest)
+	case "/events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Sink) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan []byte, 1)
+
+	s.mu.Lock()
+	if s.subscribers == nil {
+		s.subscribers = map[chan []byte]struct{}{}
+	}
+	s.subscribers[ch] = struct{}{}
+	if s.latest != nil {
+		ch <- s.latest
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case b, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "event: standings\ndata: %s\n\n", b); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// Close implements io.Closer.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	server := s.server
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	s.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	s := &Sink{}
+
+	if theme := u.Query().Get("theme"); theme != "" {
+		var err error
+		if s.Theme, err = filepath.Abs(theme); err != nil {
+			return nil, err
+		}
+	}
+
+	addr := u.Host
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(l) //nolint:errcheck
+
+	return s, nil
+}
@@ -0,0 +1,122 @@
+package overlay_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/overlay"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func get(t *testing.T, u string) string {
+	t.Helper()
+
+	res, err := http.Get(u)
+	if err != nil {
+		t.Fatalf("get %s: %v", u, err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", u, err)
+	}
+
+	return string(b)
+}
+
+// next reads the data of the next standings event from an event stream.
+func next(t *testing.T, r *bufio.Reader) *rvglutils.Event {
+	t.Helper()
+
+	var name string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v", err)
+		}
+
+		if value, ok := strings.CutPrefix(line, "event: "); ok {
+			name = strings.TrimSpace(value)
+		} else if value, ok := strings.CutPrefix(line, "data: "); ok && name == "standings" {
+			event := &rvglutils.Event{}
+			if err := json.Unmarshal([]byte(value), event); err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+
+			return event
+		}
+	}
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	theme := filepath.Join(t.TempDir(), "theme.css")
+	if err := os.WriteFile(theme, []byte("body { color: red; }"), 0644); err != nil {
+		t.Fatalf("write theme: %v", err)
+	}
+
+	var (
+		s   = &overlay.Sink{Theme: theme}
+		srv = httptest.NewServer(s)
+	)
+	defer srv.Close()
+	defer s.Close() //nolint:errcheck
+
+	if body := get(t, srv.URL+"/"); !strings.Contains(body, "EventSource") {
+		t.Errorf("expected page to subscribe to events, got %q", body)
+	}
+
+	if body := get(t, srv.URL+"/theme.css"); body != "body { color: red; }" {
+		t.Errorf("expected custom theme, got %q", body)
+	}
+
+	if body := get(t, srv.URL+"/standings.json"); body != "{}" {
+		t.Errorf("expected empty standings, got %q", body)
+	}
+
+	res, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if event := next(t, bufio.NewReader(res.Body)); event.Type != rvglutils.EventSessionFinal || len(event.Standings) == 0 {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	standings := &rvglutils.Event{}
+	if err := json.Unmarshal([]byte(get(t, srv.URL+"/standings.json")), standings); err != nil {
+		t.Fatalf("decode standings: %v", err)
+	} else if standings.Type != rvglutils.EventSessionFinal {
+		t.Errorf("expected latest standings, got %+v", standings)
+	}
+
+	// New subscribers get the latest standings right away.
+	late, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	defer late.Body.Close() //nolint:errcheck
+
+	if event := next(t, bufio.NewReader(late.Body)); event.Type != rvglutils.EventSessionFinal {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+// The methods that rvglsm calls on a plugin, in the order that it calls them.
+const (
+	// MethodOpen is called once, after the plugin starts, with OpenParams.
+	MethodOpen = "open"
+	// MethodUpdateSession is called on each update with UpdateSessionParams.
+	MethodUpdateSession = "updateSession"
+	// MethodClose is called once, before the plugin's stdin is closed.
+	MethodClose = "close"
+)
+
+// Error codes defined by JSON-RPC 2.0, plus CodeSinkError for errors from the sink itself.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeSinkError      = -32000
+)
+
+type OpenParams struct {
+	// URL is the URL that the sink was opened with, e.g. "myplugin://host/path?key=value".
+	URL string `json:"url"`
+}
+
+type UpdateSessionParams struct {
+	Event *rvglutils.Event `json:"event"`
+	// Final is whether this is the last update for the session.
+	Final bool `json:"final"`
+	// SessionCSV is the path to the session .csv, if known.
+	SessionCSV string `json:"sessionCSV,omitempty"`
+	// ScoreSessionOpts are the options that Event's standings were scored
+	// with, for the plugin to score the session with in the same way.
+	ScoreSessionOpts *rvglutils.ScoreSessionOpts `json:"scoreSessionOpts,omitempty"`
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
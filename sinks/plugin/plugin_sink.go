@@ -0,0 +1,275 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+const (
+	// CloseTimeout is how long a plugin has to exit after being closed before it is killed.
+	CloseTimeout = 5 * time.Second
+)
+
+// Sink is a sink implemented by a plugin: a program that rvglsm starts once
+// and then talks to using JSON-RPC 2.0 over the program's stdin and stdout,
+// one JSON object per line. The program's stderr is passed through.
+//
+// See MethodOpen, MethodUpdateSession and MethodClose for the methods that
+// a plugin must implement, and Serve to implement a plugin in Go.
+type Sink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	// writeMu serializes writes to the plugin's stdin separately from mu so
+	// that responses can still be read while a write is blocked on the plugin.
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu      sync.Mutex
+	id      int64
+	pending map[int64]chan *response
+	done    chan struct{}
+	err     error
+}
+
+// Start starts the plugin at path and opens it with u.
+func Start(ctx context.Context, path string, u *url.URL) (*Sink, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin %s: %w", filepath.Base(path), err)
+	}
+
+	s := &Sink{
+		cmd:     cmd,
+		stdin:   stdin,
+		enc:     json.NewEncoder(stdin),
+		pending: map[int64]chan *response{},
+		done:    make(chan struct{}),
+	}
+
+	go s.read(stdout)
+
+	if err := s.call(ctx, MethodOpen, &OpenParams{URL: u.String()}); err != nil {
+		_ = s.kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("open plugin %s: %w", filepath.Base(path), err)
+	}
+
+	return s, nil
+}
+
+// read routes the plugin's responses to the calls waiting on them.
+func (s *Sink) read(stdout io.Reader) {
+	dec := json.NewDecoder(stdout)
+
+	var err error
+	for {
+		res := &response{}
+		if err = dec.Decode(res); err != nil {
+			break
+		}
+
+		if res.ID == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		if ch, ok := s.pending[*res.ID]; ok {
+			delete(s.pending, *res.ID)
+			ch <- res
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if errors.Is(err, io.EOF) {
+		err = fmt.Errorf("plugin exited")
+	}
+
+	s.err = err
+	close(s.done)
+}
+
+func (s *Sink) call(ctx context.Context, method string, params any) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+
+	if s.err != nil {
+		defer s.mu.Unlock()
+		return s.err
+	}
+
+	s.id++
+	var (
+		id = s.id
+		ch = make(chan *response, 1)
+	)
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	s.writeMu.Lock()
+	err = s.enc.Encode(&request{JSONRPC: "2.0", ID: &id, Method: method, Params: b})
+	s.writeMu.Unlock()
+
+	if err != nil {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return err
+	}
+
+	select {
+	case res := <-ch:
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return nil
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	return s.call(ctx, MethodUpdateSession, &UpdateSessionParams{
+		Event:            event,
+		Final:            event.Final(),
+		SessionCSV:       o.SessionCSV,
+		ScoreSessionOpts: o.ScoreSessionOpts,
+	})
+}
+
+// Close implements io.Closer.
+func (s *Sink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), CloseTimeout)
+	defer cancel()
+
+	err := s.call(ctx, MethodClose, struct{}{})
+	_ = s.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- s.cmd.Wait()
+	}()
+
+	select {
+	case waitErr := <-exited:
+		return errors.Join(err, waitErr)
+	case <-ctx.Done():
+		return errors.Join(err, s.kill())
+	}
+}
+
+func (s *Sink) kill() error {
+	_ = s.stdin.Close()
+
+	if err := s.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+
+	return nil
+}
+
+type sinkOpener struct {
+	path string
+}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	return Start(ctx, o.path, u)
+}
+
+var (
+	schemeRegexp = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+)
+
+// Discover registers each executable in dir as a plugin sink for the scheme
+// that is its name, less any extension. Schemes that already have a sink are
+// skipped. It returns the schemes that were registered.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var schemes []string
+
+	for _, entry := range entries {
+		var (
+			path      = filepath.Join(dir, entry.Name())
+			scheme    = strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+			info, err = os.Stat(path)
+		)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		if !schemeRegexp.MatchString(scheme) {
+			continue
+		}
+
+		if _, ok := rvglutils.LookupSink(scheme); ok {
+			continue
+		}
+
+		rvglutils.RegisterSink(&sinkOpener{path: path}, scheme)
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes, nil
+}
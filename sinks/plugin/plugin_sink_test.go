@@ -0,0 +1,193 @@
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/plugin"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+// recorder is a sink that appends the type of each event that it handles to the file at its path.
+type recorder struct {
+	path string
+}
+
+func (r *recorder) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return r.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...))
+}
+
+func (r *recorder) HandleEvent(_ context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	return r.record(string(event.Type))
+}
+
+func (r *recorder) Close() error {
+	return r.record("closed")
+}
+
+func (r *recorder) record(line string) error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// scorer is a sink that only implements rvglutils.Sink and
+// records the leader's points each time that it is updated.
+type scorer struct {
+	path string
+}
+
+func (s *scorer) UpdateSession(_ context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	score := rvglutils.ScoreSession(session, o.ScoreSessionOpts)[0]
+	return (&recorder{path: s.path}).record(fmt.Sprintf("%s %g", score.Player, score.Points))
+}
+
+type recorderOpener struct{}
+
+func (recorderOpener) Open(_ context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Query().Has("fail") {
+		return nil, errors.New("failed to open")
+	} else if u.Scheme == "scorer" {
+		return &scorer{path: u.Query().Get("out")}, nil
+	}
+
+	return &recorder{path: u.Query().Get("out")}, nil
+}
+
+// TestMain lets the test binary stand in for a plugin.
+func TestMain(m *testing.M) {
+	if os.Getenv("PLUGIN_SINK_TEST_HELPER") != "" {
+		if err := plugin.Serve(context.Background(), recorderOpener{}, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// discover links the test executable into a plugin directory as the
+// plugin for scheme, discovers it and returns the directory.
+func discover(t *testing.T, scheme string) string {
+	t.Helper()
+
+	t.Setenv("PLUGIN_SINK_TEST_HELPER", "1")
+
+	dir := t.TempDir()
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("find test executable: %v", err)
+	}
+
+	if err := os.Symlink(executable, filepath.Join(dir, scheme)); err != nil {
+		t.Fatalf("link plugin: %v", err)
+	}
+
+	schemes, err := plugin.Discover(dir)
+	if err != nil {
+		t.Fatalf("discover plugins: %v", err)
+	}
+
+	if !slices.Equal(schemes, []string{scheme}) {
+		t.Fatalf("expected to discover %s, got %v", scheme, schemes)
+	}
+
+	return dir
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	out := filepath.Join(discover(t, "recorder"), "out")
+
+	if _, err := rvglutils.OpenSink(t.Context(), "recorder://?fail"); err == nil || !strings.Contains(err.Error(), "failed to open") {
+		t.Errorf("expected error from plugin, got %v", err)
+	}
+
+	s, err := rvglutils.OpenSink(t.Context(), "recorder://?out="+url.QueryEscape(out))
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+
+	if err := s.UpdateSession(t.Context(), session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := rvglutils.CloseSink(t.Context(), s); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+
+	if expected := "SessionUpdated\nSessionFinal\nclosed\n"; string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+func TestSinkScoreSessionOpts(t *testing.T) {
+	session := testdata.Session(t)
+
+	out := filepath.Join(discover(t, "scorer"), "out")
+
+	s, err := rvglutils.OpenSink(t.Context(), "scorer://?out="+url.QueryEscape(out))
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+
+	var (
+		scoreSessionOpts = &rvglutils.ScoreSessionOpts{
+			Handicap:           map[string]int{"FRANTJC": 100},
+			ExtraPointsPerRace: 2,
+		}
+		score = rvglutils.ScoreSession(session, scoreSessionOpts)[0]
+	)
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{ScoreSessionOpts: scoreSessionOpts}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := rvglutils.CloseSink(t.Context(), s); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+
+	if expected := fmt.Sprintf("%s %g\n", score.Player, score.Points); string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
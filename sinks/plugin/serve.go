@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+// Serve implements the plugin side of the protocol, reading requests from r
+// and writing responses to w, usually os.Stdin and os.Stdout. It opens a Sink
+// with opener and passes each update to it. It returns once the plugin is
+// closed or r is exhausted.
+func Serve(ctx context.Context, opener rvglutils.SinkOpener, r io.Reader, w io.Writer) error {
+	var (
+		dec  = json.NewDecoder(r)
+		enc  = json.NewEncoder(w)
+		sink rvglutils.Sink
+	)
+
+	for {
+		req := &request{}
+		if err := dec.Decode(req); errors.Is(err, io.EOF) {
+			if sink != nil {
+				return rvglutils.CloseSink(ctx, sink)
+			}
+
+			return nil
+		} else if err != nil {
+			_ = enc.Encode(&response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			return err
+		}
+
+		var (
+			rpcErr *Error
+			closed bool
+		)
+
+		switch req.Method {
+		case MethodOpen:
+			params := &OpenParams{}
+
+			if err := json.Unmarshal(req.Params, params); err != nil {
+				rpcErr = &Error{Code: CodeInvalidParams, Message: err.Error()}
+			} else if u, err := url.Parse(params.URL); err != nil {
+				rpcErr = &Error{Code: CodeInvalidParams, Message: err.Error()}
+			} else if sink, err = opener.Open(ctx, u); err != nil {
+				rpcErr = &Error{Code: CodeSinkError, Message: err.Error()}
+			}
+		case MethodUpdateSession:
+			params := &UpdateSessionParams{}
+
+			if err := json.Unmarshal(req.Params, params); err != nil || params.Event == nil {
+				rpcErr = &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+			} else if sink == nil {
+				rpcErr = &Error{Code: CodeInvalidRequest, Message: "sink is not open"}
+			} else if err := rvglutils.AsEventSink(sink).HandleEvent(ctx, params.Event, &rvglutils.UpdateSessionOpts{Final: params.Final, SessionCSV: params.SessionCSV, ScoreSessionOpts: params.ScoreSessionOpts}); err != nil {
+				rpcErr = &Error{Code: CodeSinkError, Message: err.Error()}
+			}
+		case MethodClose:
+			closed = true
+
+			if sink != nil {
+				if err := rvglutils.CloseSink(ctx, sink); err != nil {
+					rpcErr = &Error{Code: CodeSinkError, Message: err.Error()}
+				}
+			}
+		default:
+			rpcErr = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+		}
+
+		// Requests without an ID are notifications, which get no response.
+		if req.ID != nil {
+			res := &response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+			if rpcErr == nil {
+				res.Result = json.RawMessage("null")
+			}
+
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		}
+
+		if closed {
+			return nil
+		}
+	}
+}
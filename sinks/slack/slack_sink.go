@@ -0,0 +1,344 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "slack"
+)
+
+const (
+	DefaultAPIURL = "https://slack.com/api"
+)
+
+// Limits on blocks imposed by Slack.
+// See https://api.slack.com/reference/block-kit/blocks.
+const (
+	MaxBlocks      = 50
+	MaxHeaderText  = 150
+	MaxSectionText = 3000
+)
+
+// Sink posts standings to Slack using Block Kit.
+//
+// If Token and Channel are set, the standings are posted to Channel with
+// chat.postMessage and then kept up to date with chat.update, using TS to
+// identify the message the way the Discord sink uses its MessageID.
+// Otherwise, a new message is posted to the incoming webhook at WebhookURL
+// on each update, as incoming webhooks cannot edit their messages.
+type Sink struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+	TS         string
+	HTTPClient *http.Client
+	Template   *template.Template
+	// APIURL is the base URL of Slack's Web API, DefaultAPIURL if empty.
+	APIURL string
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	var (
+		event   = rvglutils.NewUpdateEvent(session, opts...)
+		message = map[string]any{"text": rvglutils.Title(session)}
+	)
+
+	if tmpl != nil {
+		text := strings.Builder{}
+
+		if err := tmpl.Execute(&text, event); err != nil {
+			return err
+		}
+
+		message["text"] = text.String()
+	} else {
+		message["blocks"] = Blocks(event)
+	}
+
+	if s.Token == "" {
+		return s.post(ctx, s.WebhookURL, "", message, nil)
+	}
+
+	// Pick up editing the message from a previous run against the same session.
+	var (
+		stateKey = ""
+		restored = false
+	)
+	if o.State != nil && o.SessionCSV != "" {
+		stateKey = fmt.Sprintf("%s:%s:%s", Scheme, s.Channel, o.SessionCSV)
+
+		if s.TS == "" {
+			value, ok, err := o.State.Load(stateKey)
+			if err != nil {
+				return fmt.Errorf("load message timestamp: %w", err)
+			}
+
+			// The channel's ID is stored alongside the timestamp
+			// as "<channel>:<ts>" since chat.update requires it.
+			if ok {
+				channel, ts, ok := strings.Cut(value, ":")
+				if !ok {
+					return fmt.Errorf("load message timestamp: invalid value %q", value)
+				}
+
+				s.Channel, s.TS, restored = channel, ts, true
+			}
+		}
+	}
+
+	data, err := s.send(ctx, message)
+	if restored && data != nil && data.Error == "message_not_found" {
+		// The message was deleted since, so post a new one.
+		s.TS = ""
+		data, err = s.send(ctx, message)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.TS == "" {
+		if data.TS == "" {
+			return fmt.Errorf("missing message timestamp in successful response")
+		}
+
+		s.TS = data.TS
+		// chat.update requires the channel's ID rather than its name.
+		if data.Channel != "" {
+			s.Channel = data.Channel
+		}
+
+		if stateKey != "" {
+			if err := o.State.Store(stateKey, s.Channel+":"+s.TS); err != nil {
+				return fmt.Errorf("store message timestamp: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// response is the part of a Web API method's response that the Sink uses.
+type response struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	TS      string `json:"ts"`
+	Channel string `json:"channel"`
+}
+
+// send posts message to Channel with chat.postMessage, or updates
+// it with chat.update if the Sink already has its TS. The response
+// is returned alongside the error if Slack said the call failed.
+func (s *Sink) send(ctx context.Context, message map[string]any) (*response, error) {
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	message["channel"] = s.Channel
+
+	method := "chat.postMessage"
+	if s.TS != "" {
+		method = "chat.update"
+		message["ts"] = s.TS
+	} else {
+		delete(message, "ts")
+	}
+
+	data := &response{}
+
+	if err := s.post(ctx, strings.TrimSuffix(apiURL, "/")+"/"+method, s.Token, message, data); err != nil {
+		return nil, err
+	} else if !data.OK {
+		return data, fmt.Errorf("%s: %s", method, data.Error)
+	}
+
+	return data, nil
+}
+
+func (s *Sink) post(ctx context.Context, u, token string, message, v any) error {
+	if s.HTTPClient == nil {
+		s.HTTPClient = http.DefaultClient
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("POST slack: %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+
+	if v != nil {
+		return json.Unmarshal(b, v)
+	}
+
+	return nil
+}
+
+// Blocks renders event's standings as Slack blocks: a header, sections
+// listing each player's points and change, and context with the last track.
+func Blocks(event *rvglutils.Event) []map[string]any {
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{
+				"type": "plain_text",
+				"text": truncate(fmt.Sprintf("%s, hosted by %s", event.Session.Mode, event.Session.Host), MaxHeaderText),
+			},
+		},
+	}
+
+	var (
+		lastTrack = map[string]any{}
+		section   = strings.Builder{}
+	)
+	if event.Race != nil {
+		lastTrack = map[string]any{
+			"type": "context",
+			"elements": []map[string]any{
+				{"type": "mrkdwn", "text": fmt.Sprintf("Last track: %s", Escape(event.Race.Track))},
+			},
+		}
+	}
+
+	flush := func() {
+		if section.Len() > 0 && len(blocks) < MaxBlocks-1 {
+			blocks = append(blocks, map[string]any{
+				"type": "section",
+				"text": map[string]any{"type": "mrkdwn", "text": section.String()},
+			})
+		}
+
+		section.Reset()
+	}
+
+	for _, standing := range event.Standings {
+		line := fmt.Sprintf("*%s* %s: %g (%s)\n", rvglutils.Ordinal(standing.Position), Escape(standing.Player), standing.Points, rvglutils.FormatChange(standing.Change))
+		if event.Final() && standing.Position == 1 {
+			line = fmt.Sprintf(":trophy: *WINNER! %s*: %g\n", Escape(standing.Player), standing.Points)
+		}
+
+		if utf8.RuneCountInString(section.String())+utf8.RuneCountInString(line) > MaxSectionText {
+			flush()
+		}
+
+		section.WriteString(line)
+	}
+
+	flush()
+
+	if len(lastTrack) > 0 {
+		blocks = append(blocks, lastTrack)
+	}
+
+	return blocks
+}
+
+// Escape escapes the characters that Slack's mrkdwn treats as control characters.
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	return Open(ctx, u)
+}
+
+// Open returns a Sink for the given slack:// or hooks.slack.com URL.
+func Open(_ context.Context, u *url.URL) (*Sink, error) {
+	var s *Sink
+
+	switch u.Scheme {
+	case Scheme:
+		// slack://{bot_token}@{channel}[/{ts}]
+		s = &Sink{
+			Token:   u.User.Username(),
+			Channel: u.Host,
+			TS:      strings.TrimPrefix(u.Path, "/"),
+		}
+
+		if s.Token == "" || s.Channel == "" {
+			return nil, fmt.Errorf("slack sink requires a bot token and channel")
+		}
+	case "http", "https":
+		if u.Hostname() != "hooks.slack.com" {
+			return nil, fmt.Errorf(`invalid host %q, expected "hooks.slack.com"`, u.Hostname())
+		}
+
+		webhookURL := *u
+		webhookURL.RawQuery = ""
+		s = &Sink{WebhookURL: webhookURL.String()}
+	default:
+		return nil, fmt.Errorf(`invalid scheme %q, expected %q, "http" or "https"`, u.Scheme, Scheme)
+	}
+
+	if text := u.Query().Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
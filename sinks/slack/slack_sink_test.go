@@ -0,0 +1,139 @@
+package slack_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/slack"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestSink(t *testing.T) {
+	var (
+		requests = []string{}
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			message := map[string]any{}
+			if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+
+			if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+				t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+			}
+
+			if _, ok := message["blocks"]; !ok {
+				t.Error("expected blocks")
+			}
+
+			requests = append(requests, r.URL.Path+" "+message["channel"].(string))
+			_, _ = w.Write([]byte(`{"ok":true,"channel":"C1234","ts":"1234.5678"}`))
+		}))
+		s = &slack.Sink{Token: "xoxb-token", Channel: "rvgl", APIURL: srv.URL}
+	)
+	defer srv.Close()
+
+	for range 2 {
+		if err := s.UpdateSession(t.Context(), testdata.Session(t)); err != nil {
+			t.Fatalf("update session: %v", err)
+		}
+	}
+
+	expected := []string{"/chat.postMessage rvgl", "/chat.update C1234"}
+	if !slices.Equal(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+
+	if s.TS != "1234.5678" {
+		t.Errorf("expected timestamp %q, got %q", "1234.5678", s.TS)
+	}
+}
+
+func TestSinkWebhook(t *testing.T) {
+	var (
+		requests = 0
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			_, _ = w.Write([]byte("ok"))
+		}))
+		s = &slack.Sink{WebhookURL: srv.URL}
+	)
+	defer srv.Close()
+
+	if err := s.UpdateSession(t.Context(), testdata.Session(t), &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	}))
+	defer srv.Close()
+
+	s := &slack.Sink{Token: "xoxb-token", Channel: "rvgl", APIURL: srv.URL}
+
+	if err := s.UpdateSession(t.Context(), testdata.Session(t)); err == nil || err.Error() != "chat.postMessage: channel_not_found" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSinkState(t *testing.T) {
+	var (
+		requests = []string{}
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			message := map[string]any{}
+			if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+
+			ts, _ := message["ts"].(string)
+			requests = append(requests, r.URL.Path+" "+message["channel"].(string)+" "+ts)
+
+			if ts == "deleted" {
+				_, _ = w.Write([]byte(`{"ok":false,"error":"message_not_found"}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"ok":true,"channel":"C1234","ts":"1234.5678"}`))
+		}))
+		state = &rvglutils.FileStateStore{Path: filepath.Join(t.TempDir(), "state.json")}
+		opts  = &rvglutils.UpdateSessionOpts{SessionCSV: "/session.csv", State: state}
+	)
+	defer srv.Close()
+
+	// Each iteration stands in for a restart of rvglsm.
+	for range 2 {
+		s := &slack.Sink{Token: "xoxb-token", Channel: "rvgl", APIURL: srv.URL}
+
+		if err := s.UpdateSession(t.Context(), testdata.Session(t), opts); err != nil {
+			t.Fatalf("update session: %v", err)
+		}
+	}
+
+	if err := state.Store("slack:rvgl:/session.csv", "C1234:deleted"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	if err := (&slack.Sink{Token: "xoxb-token", Channel: "rvgl", APIURL: srv.URL}).UpdateSession(t.Context(), testdata.Session(t), opts); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	expected := []string{
+		"/chat.postMessage rvgl ",
+		"/chat.update C1234 1234.5678",
+		"/chat.update C1234 deleted",
+		"/chat.postMessage C1234 ",
+	}
+	if !slices.Equal(requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,403 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme, SchemeTLS)
+}
+
+const (
+	Scheme    = "smtp"
+	SchemeTLS = "smtps"
+)
+
+// Sink emails a digest of the session, its final standings followed by
+// the results of each race, once the session is over. If Updates is set,
+// a digest of the session so far is also sent after each race.
+type Sink struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// TLSConfig is used to connect to the server over TLS, if set.
+	// Otherwise, STARTTLS is used if the server supports it.
+	TLSConfig *tls.Config
+	Username  string
+	Password  string
+	From      string
+	To        []string
+	Updates   bool
+	// Template is used to render the plain-text part of the email instead of Digest.
+	Template *template.Template
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, opts ...rvglutils.UpdateSessionOpt) error {
+	switch {
+	case event.Final():
+	case s.Updates && event.Type != rvglutils.EventSessionStarted:
+	default:
+		return nil
+	}
+
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	msg, err := s.message(event, o)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, msg)
+}
+
+var (
+	digestTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(htmltemplate.FuncMap{
+		"title":    rvglutils.Title,
+		"change":   rvglutils.FormatChange,
+		"duration": rvglutils.FormatDuration,
+		"ordinal":  rvglutils.Ordinal,
+		"inc":      func(i int) int { return i + 1 },
+	}).Parse(`<html>
+<body>
+<h1>{{ if .Final }}Final standings{{ else }}Standings{{ end }}</h1>
+<p>{{ title .Session }}</p>
+<table>
+<thead><tr><th>#</th><th>Player</th><th>Points</th><th>Change</th></tr></thead>
+<tbody>
+{{- range .Standings }}
+<tr><td>{{ ordinal .Position }}</td><td>{{ if and $.Final (eq .Position 1) }}<strong>WINNER! {{ .Player }}</strong>{{ else }}{{ .Player }}{{ end }}</td><td>{{ .Points }}</td><td>{{ change .Change }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- range $i, $race := .Session.Races }}
+<h2>Race {{ inc $i }}: {{ $race.Track }}</h2>
+<table>
+<thead><tr><th>#</th><th>Player</th><th>Car</th><th>Time</th><th>Best lap</th></tr></thead>
+<tbody>
+{{- range $race.Results }}
+<tr><td>{{ ordinal .Position }}</td><td>{{ .Player }}</td><td>{{ .Car }}</td><td>{{ if .Finished }}{{ duration .Time }}{{ else }}DNF{{ end }}</td><td>{{ duration .BestLap }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- end }}
+</body>
+</html>
+`))
+)
+
+// Digest writes a plain-text digest of event's session to w.
+func Digest(w io.Writer, event *rvglutils.Event) error {
+	heading := "Standings"
+	if event.Final() {
+		heading = "Final standings"
+	}
+
+	if _, err := fmt.Fprintf(w, "%s\n%s\n\n", heading, rvglutils.Title(event.Session)); err != nil {
+		return err
+	}
+
+	for _, standing := range event.Standings {
+		player := standing.Player
+		if event.Final() && standing.Position == 1 {
+			player = "WINNER! " + player
+		}
+
+		if _, err := fmt.Fprintf(w, "%-5s %s: %g (%s)\n", rvglutils.Ordinal(standing.Position), player, standing.Points, rvglutils.FormatChange(standing.Change)); err != nil {
+			return err
+		}
+	}
+
+	for i, race := range event.Session.Races {
+		if _, err := fmt.Fprintf(w, "\nRace %d: %s\n", i+1, race.Track); err != nil {
+			return err
+		}
+
+		for _, result := range race.Results {
+			t := "DNF"
+			if result.Finished {
+				t = rvglutils.FormatDuration(result.Time)
+			}
+
+			if _, err := fmt.Fprintf(w, "%-5s %s (%s): %s, best lap %s\n", rvglutils.Ordinal(result.Position), result.Player, result.Car, t, rvglutils.FormatDuration(result.BestLap)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// message builds the email for event: a multipart/alternative
+// message with plain-text and HTML digests of the session.
+func (s *Sink) message(event *rvglutils.Event, o *rvglutils.UpdateSessionOpts) ([]byte, error) {
+	var (
+		buf     = new(bytes.Buffer)
+		subject = fmt.Sprintf("RVGL standings: %s, hosted by %s", event.Session.Mode, event.Session.Host)
+	)
+	if event.Final() {
+		subject = fmt.Sprintf("RVGL results: %s, hosted by %s", event.Session.Mode, event.Session.Host)
+	} else if event.Race != nil {
+		subject = fmt.Sprintf("%s after race %d", subject, event.RaceNumber)
+	}
+
+	var (
+		plain = new(bytes.Buffer)
+		html  = new(bytes.Buffer)
+	)
+
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	if tmpl != nil {
+		if err := tmpl.Execute(plain, event); err != nil {
+			return nil, err
+		}
+	} else if err := Digest(plain, event); err != nil {
+		return nil, err
+	}
+
+	if err := digestTemplate.Execute(html, event); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+
+	w := multipart.NewWriter(buf)
+
+	headers := []struct{ name, value string }{
+		{"From", s.From},
+		{"To", strings.Join(s.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", w.Boundary())},
+	}
+
+	for _, header := range headers {
+		if _, err := fmt.Fprintf(buf, "%s: %s\r\n", header.name, header.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := buf.WriteString("\r\n"); err != nil {
+		return nil, err
+	}
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", plain.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(pw)
+
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, err
+		}
+
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *Sink) send(ctx context.Context, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+
+	var conn net.Conn
+	if s.TLSConfig != nil {
+		conn, err = (&tls.Dialer{Config: s.TLSConfig}).DialContext(ctx, "tcp", s.Addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close() //nolint:errcheck
+
+	if s.TLSConfig == nil {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(address(s.From)); err != nil {
+		return err
+	}
+
+	for _, to := range s.To {
+		if err := c.Rcpt(address(to)); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// address returns the bare address from an address
+// that may include a name, e.g. "RVGL <rvgl@example.com>".
+func address(s string) string {
+	if a, err := mail.ParseAddress(s); err == nil {
+		return a.Address
+	}
+
+	return s
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	var (
+		port  = "587"
+		query = u.Query()
+		s     = &Sink{
+			From: query.Get("from"),
+			To:   query["to"],
+		}
+	)
+
+	switch u.Scheme {
+	case Scheme:
+	case SchemeTLS:
+		port = "465"
+		s.TLSConfig = &tls.Config{ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("invalid scheme %q, expected %q or %q", u.Scheme, Scheme, SchemeTLS)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("smtp sink requires a host")
+	}
+
+	s.Addr = u.Host
+	if u.Port() == "" {
+		s.Addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	if u.User != nil {
+		s.Username = u.User.Username()
+		s.Password, _ = u.User.Password()
+	}
+
+	if len(s.To) == 0 {
+		return nil, fmt.Errorf("smtp sink requires at least one recipient")
+	}
+
+	if s.From == "" {
+		if s.Username == "" || !strings.Contains(s.Username, "@") {
+			return nil, fmt.Errorf("smtp sink requires a sender")
+		}
+
+		s.From = s.Username
+	}
+
+	for _, addr := range append([]string{s.From}, s.To...) {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+	}
+
+	if updates := query.Get("updates"); updates != "" {
+		var err error
+		if s.Updates, err = strconv.ParseBool(updates); err != nil {
+			return nil, fmt.Errorf("invalid updates %q: %w", updates, err)
+		}
+	}
+
+	if text := query.Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
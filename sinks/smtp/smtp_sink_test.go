@@ -0,0 +1,171 @@
+package smtp_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+type envelope struct {
+	from string
+	to   []string
+	data string
+}
+
+// newServer starts a minimal in-process SMTP server that sends each message it receives on the returned channel.
+func newServer(t *testing.T) (string, <-chan *envelope) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	envelopes := make(chan *envelope, 8)
+
+	go func() {
+		for {
+			netConn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				conn := textproto.NewConn(netConn)
+				defer conn.Close() //nolint:errcheck
+
+				env := &envelope{}
+				_ = conn.PrintfLine("220 localhost ESMTP")
+
+				for {
+					line, err := conn.ReadLine()
+					if err != nil {
+						return
+					}
+
+					verb, arg, _ := strings.Cut(line, " ")
+
+					switch strings.ToUpper(verb) {
+					case "EHLO", "HELO":
+						_ = conn.PrintfLine("250 localhost")
+					case "MAIL":
+						env.from = arg
+						_ = conn.PrintfLine("250 OK")
+					case "RCPT":
+						env.to = append(env.to, arg)
+						_ = conn.PrintfLine("250 OK")
+					case "DATA":
+						_ = conn.PrintfLine("354 Go ahead")
+
+						b, err := conn.ReadDotBytes()
+						if err != nil {
+							return
+						}
+
+						env.data = string(b)
+						envelopes <- env
+						env = &envelope{}
+						_ = conn.PrintfLine("250 OK")
+					case "QUIT":
+						_ = conn.PrintfLine("221 Bye")
+						return
+					default:
+						_ = conn.PrintfLine("502 Not implemented")
+					}
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String(), envelopes
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	addr, envelopes := newServer(t)
+
+	s, err := rvglutils.OpenSink(t.Context(), "smtp://"+addr+"?from=rvglsm@example.com&to=a@example.com&to=B+<b@example.com>")
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+
+	// Without updates, only the final update is sent.
+	if err := s.UpdateSession(t.Context(), session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	var env *envelope
+	select {
+	case env = <-envelopes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for email")
+	}
+
+	select {
+	case <-envelopes:
+		t.Error("expected only the final update to be emailed")
+	default:
+	}
+
+	if env.from != "FROM:<rvglsm@example.com>" || len(env.to) != 2 || env.to[1] != "TO:<b@example.com>" {
+		t.Errorf("unexpected envelope from %q to %q", env.from, env.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(env.data))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+
+	if subject := msg.Header.Get("Subject"); !strings.HasPrefix(subject, "RVGL results: ") {
+		t.Errorf("unexpected subject %q", subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q", msg.Header.Get("Content-Type"))
+	}
+
+	var (
+		r     = multipart.NewReader(msg.Body, params["boundary"])
+		parts = map[string]string{}
+	)
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		b, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(b)
+	}
+
+	if plain := parts["text/plain"]; !strings.Contains(plain, "WINNER! ") || !strings.Contains(plain, "Race 1: "+session.Races[0].Track) {
+		t.Errorf("unexpected plain-text part %q", plain)
+	}
+
+	if html := parts["text/html"]; !strings.Contains(html, "<table>") {
+		t.Errorf("unexpected HTML part %q", html)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"text/template"
 
 	unixtable "github.com/frantjc/go-encoding-unixtable"
 	rvglutils "github.com/frantjc/rvgl-utils"
@@ -22,6 +23,9 @@ const (
 
 type Sink struct {
 	io.Writer
+	Template *template.Template
+
+	closer io.Closer
 }
 
 // UpdateSession implements rvglutils.Sink.
@@ -32,9 +36,28 @@ func (s *Sink) UpdateSession(_ context.Context, session *rvglutils.Session, opts
 		opt.Apply(o)
 	}
 
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	if tmpl != nil {
+		return tmpl.Execute(s.Writer, rvglutils.NewUpdateEvent(session, opts...))
+	}
+
 	return unixtable.NewEncoder(s.Writer).Encode(rvglutils.ScoreSession(session, o.ScoreSessionOpts))
 }
 
+// Close implements io.Closer. It closes the file
+// that the Sink writes to if the Sink opened it.
+func (s *Sink) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+
+	return nil
+}
+
 type sinkOpener struct{}
 
 // Open implements rvglutils.SinkOpener.
@@ -45,11 +68,15 @@ func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, erro
 
 	s := &Sink{Writer: os.Stdout}
 
+	if text := u.Query().Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
 	if u.Path != "" && u.Path != "/" {
-		var (
-			path = u.Path
-			err  error
-		)
+		path := u.Path
 
 		switch u.Host {
 		case "~":
@@ -63,10 +90,13 @@ func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, erro
 			path = filepath.Join(u.Host, path)
 		}
 
-		s.Writer, err = os.Open(path)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 		if err != nil {
 			return nil, err
 		}
+
+		s.Writer = file
+		s.closer = file
 	}
 
 	return s, nil
@@ -0,0 +1,271 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "telegram"
+)
+
+const (
+	DefaultAPIURL = "https://api.telegram.org"
+	// MaxMessageText is the most characters that Telegram allows in a message.
+	MaxMessageText = 4096
+)
+
+// Sink sends standings to a Telegram chat with sendMessage
+// and then keeps them up to date with editMessageText.
+type Sink struct {
+	Token      string
+	ChatID     string
+	MessageID  int
+	HTTPClient *http.Client
+	Template   *template.Template
+	// APIURL is the base URL of Telegram's Bot API, DefaultAPIURL if empty.
+	APIURL string
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	o := new(rvglutils.UpdateSessionOpts)
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	tmpl := s.Template
+	if tmpl == nil {
+		tmpl = o.Template
+	}
+
+	var (
+		event   = rvglutils.NewUpdateEvent(session, opts...)
+		message = map[string]any{"chat_id": s.ChatID}
+	)
+
+	if tmpl != nil {
+		text := strings.Builder{}
+
+		if err := tmpl.Execute(&text, event); err != nil {
+			return err
+		}
+
+		message["text"] = truncate(text.String(), MaxMessageText)
+	} else {
+		message["text"] = Text(event)
+		message["parse_mode"] = "MarkdownV2"
+	}
+
+	// Pick up editing the message from a previous run against the same session.
+	stateKey := ""
+	if o.State != nil && o.SessionCSV != "" {
+		stateKey = fmt.Sprintf("%s:%s:%s", Scheme, s.ChatID, o.SessionCSV)
+
+		if s.MessageID == 0 {
+			value, ok, err := o.State.Load(stateKey)
+			if err != nil {
+				return fmt.Errorf("load message ID: %w", err)
+			}
+
+			if ok {
+				if s.MessageID, err = strconv.Atoi(value); err != nil {
+					return fmt.Errorf("load message ID: %w", err)
+				}
+			}
+		}
+	}
+
+	method := "sendMessage"
+	if s.MessageID != 0 {
+		method = "editMessageText"
+		message["message_id"] = s.MessageID
+	}
+
+	data := struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Result      struct {
+			MessageID int `json:"message_id"`
+		} `json:"result"`
+	}{}
+
+	if err := s.call(ctx, method, message, &data); err != nil {
+		return err
+	} else if !data.OK {
+		// Telegram refuses edits that would not change the message.
+		if method == "editMessageText" && strings.Contains(data.Description, "message is not modified") {
+			return nil
+		}
+
+		return fmt.Errorf("%s: %s", method, data.Description)
+	}
+
+	if s.MessageID == 0 {
+		if data.Result.MessageID == 0 {
+			return fmt.Errorf("missing message ID in successful response")
+		}
+
+		s.MessageID = data.Result.MessageID
+
+		if stateKey != "" {
+			if err := o.State.Store(stateKey, strconv.Itoa(s.MessageID)); err != nil {
+				return fmt.Errorf("store message ID: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Sink) call(ctx context.Context, method string, message, v any) error {
+	if s.HTTPClient == nil {
+		s.HTTPClient = http.DefaultClient
+	}
+
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/%s", strings.TrimSuffix(apiURL, "/"), s.Token, method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.HTTPClient.Do(req)
+	if err != nil {
+		// The error's URL includes the bot's token, so leave it out.
+		if urlErr := (*url.Error)(nil); errors.As(err, &urlErr) {
+			return fmt.Errorf("%s: %w", method, urlErr.Err)
+		}
+
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	// Telegram describes errors in the body of unsuccessful responses, too.
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: %s", method, res.Status)
+	}
+
+	return nil
+}
+
+// Text renders event's standings as a Telegram MarkdownV2 message.
+func Text(event *rvglutils.Event) string {
+	text := strings.Builder{}
+
+	text.WriteString(fmt.Sprintf("*%s*\n", Escape(fmt.Sprintf("%s, hosted by %s", event.Session.Mode, event.Session.Host))))
+
+	for _, standing := range event.Standings {
+		line := Escape(fmt.Sprintf("%s %s: %g (%s)", rvglutils.Ordinal(standing.Position), standing.Player, standing.Points, rvglutils.FormatChange(standing.Change))) + "\n"
+		if event.Final() && standing.Position == 1 {
+			line = fmt.Sprintf("*%s*\n", Escape(fmt.Sprintf("WINNER! %s: %g", standing.Player, standing.Points)))
+		}
+
+		if utf8.RuneCountInString(text.String())+utf8.RuneCountInString(line) > MaxMessageText {
+			break
+		}
+
+		text.WriteString(line)
+	}
+
+	if event.Race != nil {
+		line := fmt.Sprintf("_%s_", Escape("Last track: "+event.Race.Track))
+
+		if utf8.RuneCountInString(text.String())+utf8.RuneCountInString(line) <= MaxMessageText {
+			text.WriteString(line)
+		}
+	}
+
+	return text.String()
+}
+
+var (
+	escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+)
+
+// Escape escapes s for use in a Telegram MarkdownV2 message.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	// Bot tokens look like "123456:ABC-DEF", so they parse as a username and password.
+	token := u.User.Username()
+	if password, ok := u.User.Password(); ok {
+		token += ":" + password
+	}
+
+	var (
+		query = u.Query()
+		s     = &Sink{
+			Token:  token,
+			ChatID: u.Host,
+			APIURL: query.Get("api"),
+		}
+	)
+
+	if s.Token == "" || s.ChatID == "" {
+		return nil, fmt.Errorf("telegram sink requires a bot token and chat ID")
+	}
+
+	if messageID := strings.TrimPrefix(u.Path, "/"); messageID != "" {
+		var err error
+		if s.MessageID, err = strconv.Atoi(messageID); err != nil {
+			return nil, fmt.Errorf("invalid message ID %q: %w", messageID, err)
+		}
+	}
+
+	if text := query.Get("template"); text != "" {
+		var err error
+		if s.Template, err = rvglutils.ParseTemplate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
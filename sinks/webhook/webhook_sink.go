@@ -0,0 +1,244 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme, SchemeTLS)
+}
+
+const (
+	// Scheme and SchemeTLS mark a URL as a generic webhook, so that
+	// "webhook+https://example.com/hook" is POSTed to "https://example.com/hook".
+	Scheme    = "webhook+http"
+	SchemeTLS = "webhook+https"
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 5
+	DefaultBackoff    = time.Second
+)
+
+const (
+	// SignatureHeader is the header that holds the signature
+	// of a request's body when the Sink has a Secret.
+	SignatureHeader = "X-RVGLSM-Signature"
+	// EventHeader is the header that holds the type of the Event in a request's body.
+	EventHeader = "X-RVGLSM-Event"
+)
+
+// Sink POSTs each Event as JSON to URL. The body is the JSON encoding of
+// rvglutils.Event: the event's "type", the "session" as of the event, the
+// latest "race" and its "raceNumber" if any, and the "standings".
+//
+// If Secret is set, the body is signed with HMAC-SHA256 using it, and the
+// signature is sent hex-encoded in SignatureHeader as "sha256=<signature>".
+type Sink struct {
+	URL        string
+	Header     http.Header
+	Secret     string
+	HTTPClient *http.Client
+	// Timeout is how long each attempt at a request may take,
+	// DefaultTimeout if not positive.
+	Timeout time.Duration
+	// MaxRetries is the number of times to retry a failed request,
+	// DefaultMaxRetries if not positive.
+	MaxRetries int
+	// Backoff is how long to wait before the first retry of a failed
+	// request, DefaultBackoff if not positive. It doubles with each retry.
+	Backoff time.Duration
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(ctx context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	if s.HTTPClient == nil {
+		s.HTTPClient = http.DefaultClient
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var (
+		timeout    = s.Timeout
+		maxRetries = s.MaxRetries
+		backoff    = s.Backoff
+	)
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	// Requests that fail due to the network, a rate limit or a server error
+	// are retried with exponential backoff, or after as long as the response's
+	// Retry-After header says, up to MaxRetries times.
+	for attempt := 0; ; attempt++ {
+		retry, wait, err := s.post(ctx, timeout, event.Type, body)
+		if err == nil || !retry || attempt >= maxRetries {
+			return err
+		}
+
+		if wait <= 0 {
+			wait = backoff << attempt
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// post makes a single attempt at POSTing body to URL. If it fails, it
+// reports whether it is worth retrying and, if the response says,
+// how long to wait before doing so.
+func (s *Sink) post(ctx context.Context, timeout time.Duration, eventType rvglutils.EventType, body []byte) (bool, time.Duration, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+
+	for name, values := range s.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(eventType))
+
+	if s.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign([]byte(s.Secret), body))
+	}
+
+	res, err := s.HTTPClient.Do(req)
+	if err != nil {
+		// Failing because the attempt timed out is worth retrying,
+		// failing because ctx is done is not.
+		return ctx.Err() == nil, 0, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, 0, nil
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	err = fmt.Errorf("POST webhook: %s: %s", res.Status, strings.TrimSpace(string(b)))
+
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500 {
+		return false, 0, err
+	}
+
+	var wait time.Duration
+	if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	return true, wait, err
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body using secret,
+// for receivers to compare against SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	return Open(ctx, u)
+}
+
+// Open returns a Sink for the given webhook+http(s) URL. The "header" query
+// parameter can be repeated to send extra headers like "Authorization: Bearer
+// token", the "secret" query parameter sets the Sink's Secret and "timeout"
+// sets its Timeout. They are removed from the URL that the Sink POSTs to.
+func Open(_ context.Context, u *url.URL) (*Sink, error) {
+	switch u.Scheme {
+	case Scheme, SchemeTLS:
+	default:
+		return nil, fmt.Errorf("invalid scheme %q, expected %q or %q", u.Scheme, Scheme, SchemeTLS)
+	}
+
+	var (
+		query = u.Query()
+		s     = &Sink{Header: http.Header{}, Secret: query.Get("secret")}
+	)
+
+	for _, header := range query["header"] {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+		}
+
+		s.Header.Add(textproto.TrimString(name), textproto.TrimString(value))
+	}
+
+	if timeout := query.Get("timeout"); timeout != "" {
+		var err error
+		if s.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+		}
+	}
+
+	query.Del("header")
+	query.Del("secret")
+	query.Del("timeout")
+
+	target := *u
+	target.Scheme = strings.TrimPrefix(u.Scheme, "webhook+")
+	target.RawQuery = query.Encode()
+	s.URL = target.String()
+
+	return s, nil
+}
@@ -0,0 +1,128 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/webhook"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		event = &rvglutils.Event{}
+		srv   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			if r.URL.RawQuery != "league=1" {
+				t.Errorf("unexpected query %q", r.URL.RawQuery)
+			}
+
+			if r.Header.Get("Authorization") != "Bearer token" {
+				t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+			}
+
+			if r.Header.Get(webhook.SignatureHeader) != "sha256="+webhook.Sign([]byte("secret"), body) {
+				t.Errorf("unexpected signature %q", r.Header.Get(webhook.SignatureHeader))
+			}
+
+			if err := json.Unmarshal(body, event); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+		}))
+	)
+	defer srv.Close()
+
+	u, err := url.Parse("webhook+" + srv.URL + "?league=1&secret=secret&header=Authorization:+Bearer+token")
+	if err != nil {
+		t.Fatalf("parse URL: %v", err)
+	}
+
+	s, err := webhook.Open(t.Context(), u)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if event.Type != rvglutils.EventSessionFinal {
+		t.Errorf("expected event type %q, got %q", rvglutils.EventSessionFinal, event.Type)
+	}
+
+	if len(event.Standings) == 0 {
+		t.Error("expected standings")
+	}
+}
+
+func TestSinkRetry(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		attempts = &atomic.Int32{}
+		srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch attempts.Add(1) {
+			case 1:
+				// Outlast the Sink's Timeout.
+				time.Sleep(100 * time.Millisecond)
+			case 2:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+	)
+	defer srv.Close()
+
+	s := &webhook.Sink{URL: srv.URL, Timeout: 50 * time.Millisecond, Backoff: time.Millisecond}
+
+	if err := s.UpdateSession(t.Context(), session); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if n := attempts.Load(); n != 3 {
+		t.Fatal("unexpected number of attempts:", n)
+	}
+
+	s.MaxRetries = 1
+	attempts.Store(0)
+
+	if err := s.UpdateSession(t.Context(), session); err == nil {
+		t.Fatal("expected error after running out of retries")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, rawURL := range []string{"https://example.com/hook", "http://example.com/hook"} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatalf("parse URL: %v", err)
+		}
+
+		if _, err := webhook.Open(t.Context(), u); err == nil {
+			t.Errorf("expected error opening %q without the webhook+ prefix", rawURL)
+		}
+	}
+
+	u, err := url.Parse("webhook+https://example.com/hook?timeout=5s")
+	if err != nil {
+		t.Fatalf("parse URL: %v", err)
+	}
+
+	s, err := webhook.Open(t.Context(), u)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if s.URL != "https://example.com/hook" || s.Timeout != 5*time.Second {
+		t.Fatalf("unexpected sink: %+v", s)
+	}
+}
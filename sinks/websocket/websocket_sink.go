@@ -0,0 +1,204 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	rvglutils.RegisterSink(&sinkOpener{}, Scheme)
+}
+
+const (
+	Scheme = "ws"
+)
+
+const (
+	DefaultAddr = ":8081"
+	// PingInterval is how often clients are pinged to keep their connections alive.
+	PingInterval = 30 * time.Second
+	// WriteTimeout is how long a client has to accept a message before it is disconnected.
+	WriteTimeout = 10 * time.Second
+	// clientBuffer is how many messages can be waiting on
+	// a client before it is disconnected for being too slow.
+	clientBuffer = 16
+)
+
+// Sink pushes each Event as JSON to the WebSocket clients connected to it.
+// When a client connects, it is first sent the latest Event as a snapshot.
+//
+// Sink is an http.Handler, so it can be mounted in another server,
+// or it can be opened from a URL like ws://:8081/standings to serve it
+// on its own at that path.
+type Sink struct {
+	// CheckOrigin decides whether to accept a connection from another origin.
+	// All origins are accepted if nil, as the feed is meant for any dashboard.
+	CheckOrigin func(*http.Request) bool
+
+	mu      sync.Mutex
+	latest  []byte
+	clients map[chan []byte]struct{}
+	server  *http.Server
+}
+
+// UpdateSession implements rvglutils.Sink.
+func (s *Sink) UpdateSession(ctx context.Context, session *rvglutils.Session, opts ...rvglutils.UpdateSessionOpt) error {
+	return s.HandleEvent(ctx, rvglutils.NewUpdateEvent(session, opts...), opts...)
+}
+
+// HandleEvent implements rvglutils.EventSink.
+func (s *Sink) HandleEvent(_ context.Context, event *rvglutils.Event, _ ...rvglutils.UpdateSessionOpt) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latest = b
+
+	for ch := range s.clients {
+		select {
+		case ch <- b:
+		default:
+			// The client is too far behind, so disconnect it.
+			close(ch)
+			delete(s.clients, ch)
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	checkOrigin := s.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = func(*http.Request) bool { return true }
+	}
+
+	conn, err := (&websocket.Upgrader{CheckOrigin: checkOrigin}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close() //nolint:errcheck
+
+	ch := make(chan []byte, clientBuffer)
+
+	s.mu.Lock()
+	if s.clients == nil {
+		s.clients = map[chan []byte]struct{}{}
+	}
+	s.clients[ch] = struct{}{}
+	if s.latest != nil {
+		ch <- s.latest
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	// Read to handle control frames and notice when the client goes away.
+	// Messages from clients are ignored.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case b, ok := <-ch:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(WriteTimeout))
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+			if err := conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close implements io.Closer.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	server := s.server
+	for ch := range s.clients {
+		close(ch)
+		delete(s.clients, ch)
+	}
+	s.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
+
+type sinkOpener struct{}
+
+// Open implements rvglutils.SinkOpener.
+func (o *sinkOpener) Open(ctx context.Context, u *url.URL) (rvglutils.Sink, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("invalid scheme %q, expected %q", u.Scheme, Scheme)
+	}
+
+	var (
+		s    = &Sink{}
+		addr = u.Host
+		path = u.Path
+	)
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, s)
+
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(l) //nolint:errcheck
+
+	return s, nil
+}
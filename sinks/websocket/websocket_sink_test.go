@@ -0,0 +1,60 @@
+package websocket_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/sinks/websocket"
+	"github.com/frantjc/rvgl-utils/testdata"
+	gorilla "github.com/gorilla/websocket"
+)
+
+func receive(t *testing.T, conn *gorilla.Conn) *rvglutils.Event {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	event := &rvglutils.Event{}
+	if err := conn.ReadJSON(event); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+
+	return event
+}
+
+func TestSink(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		s   = &websocket.Sink{}
+		srv = httptest.NewServer(s)
+	)
+	defer srv.Close()
+	defer s.Close() //nolint:errcheck
+
+	if err := s.HandleEvent(t.Context(), rvglutils.NewEvent(rvglutils.EventRaceCompleted, session)); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+
+	conn, _, err := gorilla.DefaultDialer.DialContext(t.Context(), "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	// The latest event is sent as a snapshot on connect.
+	if event := receive(t, conn); event.Type != rvglutils.EventRaceCompleted || event.RaceNumber != len(session.Races) {
+		t.Errorf("unexpected snapshot %+v", event)
+	}
+
+	if err := s.UpdateSession(t.Context(), session, &rvglutils.UpdateSessionOpts{Final: true}); err != nil {
+		t.Fatalf("update session: %v", err)
+	}
+
+	if event := receive(t, conn); event.Type != rvglutils.EventSessionFinal || len(event.Standings) == 0 {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,86 @@
+package rvglutils
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateStore is somewhere for sinks to keep small pieces of state, such as
+// the ID of a message that they are editing, across restarts of rvglsm.
+type StateStore interface {
+	// Load returns the value stored at key, if any.
+	Load(key string) (string, bool, error)
+	// Store stores value at key.
+	Store(key, value string) error
+}
+
+// FileStateStore is a StateStore that keeps its state in a JSON file at Path.
+type FileStateStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileStateStore) read() (map[string]string, error) {
+	state := map[string]string{}
+
+	b, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Load implements StateStore.
+func (s *FileStateStore) Load(key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.read()
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := state[key]
+	return value, ok, nil
+}
+
+// Store implements StateStore.
+func (s *FileStateStore) Store(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	state[key] = value
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.Path + ".tmp"
+
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.Path)
+}
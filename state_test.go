@@ -0,0 +1,31 @@
+package rvglutils_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+func TestFileStateStore(t *testing.T) {
+	var (
+		name = filepath.Join(t.TempDir(), "rvglsm", "state.json")
+		s    = &rvglutils.FileStateStore{Path: name}
+	)
+
+	if _, ok, err := s.Load("key"); err != nil {
+		t.Fatalf("load: %v", err)
+	} else if ok {
+		t.Error("expected no value before store")
+	}
+
+	if err := s.Store("key", "value"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	if value, ok, err := (&rvglutils.FileStateStore{Path: name}).Load("key"); err != nil {
+		t.Fatalf("load: %v", err)
+	} else if !ok || value != "value" {
+		t.Errorf("expected %q, got %q", "value", value)
+	}
+}
@@ -0,0 +1,146 @@
+package rvglutils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type DraftTeamsOpts struct {
+	Teams            int
+	ScoreSessionOpts *ScoreSessionOpts
+}
+
+func (o *DraftTeamsOpts) Apply(opts *DraftTeamsOpts) {
+	if o != nil {
+		if opts != nil {
+			if o.Teams > 0 {
+				opts.Teams = o.Teams
+			}
+			if o.ScoreSessionOpts != nil {
+				opts.ScoreSessionOpts = o.ScoreSessionOpts
+			}
+		}
+	}
+}
+
+type DraftTeamsOpt interface {
+	Apply(*DraftTeamsOpts)
+}
+
+// Teams maps each team's name to its players.
+type Teams map[string][]string
+
+func newDraftTeamsOpts(opts ...DraftTeamsOpt) *DraftTeamsOpts {
+	o := &DraftTeamsOpts{
+		Teams: 2,
+	}
+
+	for _, opt := range opts {
+		opt.Apply(o)
+	}
+
+	return o
+}
+
+// DraftTeams splits players into Teams teams named "Team 1", "Team 2" and so on
+// such that the teams' average ratings from the given sessions are as close as
+// possible. Players without any history are rated as the average player.
+func DraftTeams(players []string, sessions []*Session, opts ...DraftTeamsOpt) (Teams, error) {
+	o := newDraftTeamsOpts(opts...)
+
+	if o.Teams > len(players) {
+		return nil, fmt.Errorf("cannot draft %d players into %d teams", len(players), o.Teams)
+	}
+
+	var (
+		ratings = map[string]float64{}
+		total   float64
+	)
+	for _, rating := range RatePlayers(sessions, o.ScoreSessionOpts, &ScoreSessionOpts{IncludeAI: true}) {
+		ratings[rating.Player] = rating.Points
+		total += rating.Points
+	}
+
+	average := 0.0
+	if len(ratings) > 0 {
+		average = total / float64(len(ratings))
+	}
+
+	rate := func(player string) float64 {
+		if rating, ok := ratings[player]; ok {
+			return rating
+		}
+
+		return average
+	}
+
+	sorted := append([]string{}, players...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rate(sorted[i]) > rate(sorted[j])
+	})
+
+	// Start with a snake draft so that team sizes differ by at most one.
+	teams := make([][]string, o.Teams)
+	for i, player := range sorted {
+		var (
+			round = i / o.Teams
+			pick  = i % o.Teams
+		)
+		if round%2 == 1 {
+			pick = o.Teams - 1 - pick
+		}
+
+		teams[pick] = append(teams[pick], player)
+	}
+
+	spread := func() float64 {
+		var (
+			lo = math.Inf(1)
+			hi = math.Inf(-1)
+		)
+		for _, team := range teams {
+			sum := 0.0
+			for _, player := range team {
+				sum += rate(player)
+			}
+
+			avg := sum / float64(len(team))
+			lo = math.Min(lo, avg)
+			hi = math.Max(hi, avg)
+		}
+
+		return hi - lo
+	}
+
+	// Then swap players between teams for as long as doing so
+	// brings the teams' average ratings closer together.
+	for improved := true; improved; {
+		improved = false
+		best := spread()
+
+		for a := range teams {
+			for b := a + 1; b < len(teams); b++ {
+				for i := range teams[a] {
+					for j := range teams[b] {
+						teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+
+						if s := spread(); s < best-1e-9 {
+							best = s
+							improved = true
+						} else {
+							teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+						}
+					}
+				}
+			}
+		}
+	}
+
+	drafted := make(Teams, o.Teams)
+	for i, team := range teams {
+		drafted[fmt.Sprintf("Team %d", i+1)] = team
+	}
+
+	return drafted, nil
+}
@@ -0,0 +1,61 @@
+package rvglutils_test
+
+import (
+	"math"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestDraftTeams(t *testing.T) {
+	session := testdata.Session(t)
+
+	var (
+		players = []string{"FRANTJC", "Glacier", "Probe 24", "Sir Gleam", "Karen"}
+		teams   []string
+	)
+
+	drafted, err := rvglutils.DraftTeams(players, []*rvglutils.Session{session}, &rvglutils.DraftTeamsOpts{Teams: 2})
+	if err != nil {
+		t.Fatalf("draft teams: %v", err)
+	}
+
+	if len(drafted) != 2 {
+		t.Fatal("unexpected number of teams:", len(drafted))
+	}
+
+	ratings := map[string]float64{}
+	for _, rating := range rvglutils.RatePlayers([]*rvglutils.Session{session}, &rvglutils.ScoreSessionOpts{IncludeAI: true}) {
+		ratings[rating.Player] = rating.Points
+	}
+
+	averages := []float64{}
+	for _, team := range drafted {
+		teams = append(teams, team...)
+
+		if len(team) < 2 || len(team) > 3 {
+			t.Fatal("unbalanced team size:", len(team))
+		}
+
+		sum := 0.0
+		for _, player := range team {
+			sum += ratings[player]
+		}
+		averages = append(averages, sum/float64(len(team)))
+	}
+
+	if len(teams) != len(players) {
+		t.Fatal("unexpected number of drafted players:", len(teams))
+	}
+
+	if math.Abs(averages[0]-averages[1]) > 2 {
+		t.Fatalf("teams not balanced: %g vs %g", averages[0], averages[1])
+	}
+}
+
+func TestDraftTeamsTooMany(t *testing.T) {
+	if _, err := rvglutils.DraftTeams([]string{"FRANTJC"}, nil, &rvglutils.DraftTeamsOpts{Teams: 2}); err == nil {
+		t.Fatal("expected error drafting 1 player into 2 teams")
+	}
+}
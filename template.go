@@ -0,0 +1,81 @@
+package rvglutils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	// TemplateFuncs are the functions available to templates
+	// parsed by ParseTemplate in addition to text/template's.
+	TemplateFuncs = template.FuncMap{
+		"title":    Title,
+		"change":   FormatChange,
+		"duration": FormatDuration,
+		"ordinal":  Ordinal,
+		"bracket": func(b *Bracket) (string, error) {
+			text := strings.Builder{}
+			if b != nil {
+				if err := EncodeBracketText(&text, b); err != nil {
+					return "", err
+				}
+			}
+
+			return text.String(), nil
+		},
+		"padLeft": func(width int, v any) string {
+			return fmt.Sprintf("%*v", width, v)
+		},
+		"padRight": func(width int, v any) string {
+			return fmt.Sprintf("%-*v", width, v)
+		},
+	}
+)
+
+// ParseTemplate parses a text/template for rendering an Event in a sink.
+// If text begins with "@", the template is read from the file at the path
+// following it instead.
+//
+// Templates are executed with the Event as data, so they can use its Type,
+// Session, Race (the last race), Standings, Final and, once the session
+// has been recorded as a heat, the Bracket that it was recorded in.
+func ParseTemplate(text string) (*template.Template, error) {
+	if name, ok := strings.CutPrefix(text, "@"); ok {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		text = string(b)
+	}
+
+	return template.New("sink").Funcs(TemplateFuncs).Parse(text)
+}
+
+// FormatDuration formats d the same way as RVGL does in session .csvs, e.g. "01:23:456".
+func FormatDuration(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%03d", int(d/time.Minute), int(d%time.Minute/time.Second), int(d%time.Second/time.Millisecond))
+}
+
+// Ordinal formats n as an ordinal number, e.g. "1st", "2nd" or "11th".
+func Ordinal(n int) string {
+	suffix := "th"
+
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
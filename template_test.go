@@ -0,0 +1,68 @@
+package rvglutils_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+	"github.com/frantjc/rvgl-utils/testdata"
+)
+
+func TestParseTemplate(t *testing.T) {
+	session := testdata.Session(t)
+
+	tmpl, err := rvglutils.ParseTemplate(`{{ with index .Standings 0 }}{{ ordinal .Position }} {{ padRight 8 .Player }}|{{ padLeft 3 .Points }}{{ end }} {{ duration (index .Race.Results 0).Time }}{{ if .Final }} final{{ end }}`)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := tmpl.Execute(buf, rvglutils.NewEvent(rvglutils.EventSessionFinal, session)); err != nil {
+		t.Fatalf("execute template: %v", err)
+	}
+
+	if expected := "1st FRANTJC | 47 00:30:537 final"; buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestParseTemplateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "template.txt")
+
+	if err := os.WriteFile(name, []byte("{{ .Type }}"), 0644); err != nil {
+		t.Fatalf("write %q: %v", name, err)
+	}
+
+	tmpl, err := rvglutils.ParseTemplate("@" + name)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := tmpl.Execute(buf, &rvglutils.Event{Type: rvglutils.EventRaceCompleted}); err != nil {
+		t.Fatalf("execute template: %v", err)
+	}
+
+	if buf.String() != string(rvglutils.EventRaceCompleted) {
+		t.Fatal("unexpected output:", buf.String())
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, expected := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 112: "112th"} {
+		if s := rvglutils.Ordinal(n); s != expected {
+			t.Fatalf("expected %q, got %q", expected, s)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	if s := rvglutils.FormatDuration(time.Minute + 23*time.Second + 456*time.Millisecond); s != "01:23:456" {
+		t.Fatal("unexpected duration:", s)
+	}
+}
@@ -0,0 +1,20 @@
+package testdata
+
+import (
+	"bytes"
+	"testing"
+
+	rvglutils "github.com/frantjc/rvgl-utils"
+)
+
+// Session decodes SessionCSV, failing t if it cannot.
+func Session(t testing.TB) *rvglutils.Session {
+	t.Helper()
+
+	session, err := rvglutils.DecodeSessionCSV(bytes.NewReader(SessionCSV))
+	if err != nil {
+		t.Fatalf("decode testdata/session.csv: %v", err)
+	}
+
+	return session
+}